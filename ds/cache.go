@@ -0,0 +1,701 @@
+package ds
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+)
+
+// ErrNoLoader is returned by Cache.Load for missing keys when the cache has
+// no Loader.
+var ErrNoLoader = errors.New("ds: cache has no loader")
+
+// EvictionPolicy defines the policy used by a Cache to pick the entries
+// evicted when it is full.
+type EvictionPolicy int
+
+// contains the eviction policies supported by the Cache.
+const (
+	// LRU evicts the least recently used entry.
+	LRU EvictionPolicy = iota
+
+	// LFU evicts the least frequently used entry.
+	LFU
+
+	// ARC evicts using the Adaptive Replacement Cache policy, which adapts
+	// between recency and frequency based on the recently evicted keys.
+	ARC
+)
+
+// EvictReason defines the reason an entry was removed from a Cache.
+type EvictReason int
+
+// contains the reasons delivered to CacheConfig.OnEvict.
+const (
+	// Evicted reports an entry removed by the EvictionPolicy to free space.
+	Evicted EvictReason = iota
+
+	// Expired reports an entry removed after its TTL passed.
+	Expired
+
+	// Removed reports an entry removed by Remove or Clear.
+	Removed
+)
+
+// String returns the name of the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case Evicted:
+		return "evicted"
+	case Expired:
+		return "expired"
+	default:
+		return "removed"
+	}
+}
+
+// LoaderFunc defines a function which loads the value of a key missing from a
+// Cache.
+type LoaderFunc func(key string) (interface{}, error)
+
+// CacheConfig defines the settings used by NewCache.
+type CacheConfig struct {
+	// Policy sets the eviction policy, it defaults to LRU.
+	Policy EvictionPolicy
+
+	// MaxItems sets the max number of entries, zero for no limit.
+	MaxItems int
+
+	// MaxBytes sets the max estimated size of all entries, zero for no limit.
+	MaxBytes int64
+
+	// Sizer sets the function estimating the size of an entry for MaxBytes, it
+	// defaults to the length of the key plus EstimateSize of the value.
+	Sizer func(key string, value interface{}) int64
+
+	// TTL sets the time to live of entries added with Set, zero for no expiry.
+	TTL time.Duration
+
+	// Loader sets the function used by Load for missing keys.
+	Loader LoaderFunc
+
+	// OnEvict is called with every entry removed from the cache. It is called
+	// after the lock of the cache is released, so it may use the cache.
+	OnEvict func(key string, value interface{}, reason EvictReason)
+}
+
+// CacheStats defines the counters of a Cache.
+type CacheStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Loads       int64 `json:"loads"`
+	LoadErrors  int64 `json:"load_errors"`
+	Evictions   int64 `json:"evictions"`
+	Expirations int64 `json:"expirations"`
+	Items       int   `json:"items"`
+	Bytes       int64 `json:"bytes"`
+}
+
+// HitRatio returns the ratio of hits to all lookups.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Entry returns a metrics.Entry holding the stats as fields.
+func (s CacheStats) Entry() metrics.Entry {
+	return metrics.WithFields(metrics.Fields{
+		"hits":        s.Hits,
+		"misses":      s.Misses,
+		"hit_ratio":   s.HitRatio(),
+		"loads":       s.Loads,
+		"load_errors": s.LoadErrors,
+		"evictions":   s.Evictions,
+		"expirations": s.Expirations,
+		"items":       s.Items,
+		"bytes":       s.Bytes,
+	}).WithMessage("ds: cache stats")
+}
+
+// cacheEntry defines a single entry of a Cache along with the bookkeeping of
+// its eviction policy.
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	size    int64
+	expires time.Time
+
+	// elem sets the list element used by the LRU and ARC policies.
+	elem *list.Element
+
+	// frequent sets if the entry is held in the t2 list of the ARC policy.
+	frequent bool
+
+	// freq, tick and index are used by the LFU policy.
+	freq  int
+	tick  uint64
+	index int
+}
+
+// expired returns true/false if the entry has expired at the giving time.
+func (e *cacheEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// cacheCall defines a Load in flight, shared by concurrent callers of the
+// same key.
+type cacheCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+
+	// stale sets if the key was changed while loading, so the loaded value
+	// must not replace it.
+	stale bool
+}
+
+// cacheEviction defines a removed entry waiting for delivery to OnEvict.
+type cacheEviction struct {
+	key    string
+	value  interface{}
+	reason EvictReason
+}
+
+// Cache provides a concurrent Maps with per-entry expiry, bounded size and
+// LRU, LFU or ARC eviction. Expired entries are removed lazily when accessed,
+// or with Purge.
+type Cache struct {
+	config CacheConfig
+
+	ml      sync.Mutex
+	items   map[string]*cacheEntry
+	policy  evictor
+	bytes   int64
+	calls   map[string]*cacheCall
+	stats   CacheStats
+	evicted []cacheEviction
+}
+
+// NewCache returns a new Cache instance using the giving config.
+func NewCache(config CacheConfig) *Cache {
+	if config.Sizer == nil {
+		config.Sizer = func(key string, value interface{}) int64 {
+			return int64(len(key)) + EstimateSize(value)
+		}
+	}
+
+	return &Cache{
+		config: config,
+		items:  make(map[string]*cacheEntry),
+		policy: newEvictor(config.Policy),
+		calls:  make(map[string]*cacheCall),
+	}
+}
+
+// unlock releases the lock of the cache and delivers the entries removed
+// while it was held to OnEvict.
+func (c *Cache) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.ml.Unlock()
+
+	if c.config.OnEvict == nil {
+		return
+	}
+
+	for _, item := range evicted {
+		c.config.OnEvict(item.key, item.value, item.reason)
+	}
+}
+
+// remove deletes the entry for the giving reason, the lock must be held.
+func (c *Cache) remove(e *cacheEntry, reason EvictReason) {
+	c.changed(e.key)
+	delete(c.items, e.key)
+	c.policy.remove(e, reason == Evicted)
+	c.bytes -= e.size
+
+	switch reason {
+	case Evicted:
+		c.stats.Evictions++
+	case Expired:
+		c.stats.Expirations++
+	}
+
+	if c.config.OnEvict != nil {
+		c.evicted = append(c.evicted, cacheEviction{key: e.key, value: e.value, reason: reason})
+	}
+}
+
+// changed marks a load in flight for the key as stale, the lock must be held.
+func (c *Cache) changed(k string) {
+	if call, ok := c.calls[k]; ok {
+		call.stale = true
+	}
+}
+
+// entry returns the live entry of the key, removing it if expired, the lock
+// must be held.
+func (c *Cache) entry(k string) (*cacheEntry, bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return nil, false
+	}
+
+	if e.expired(time.Now()) {
+		c.remove(e, Expired)
+		return nil, false
+	}
+
+	return e, true
+}
+
+// set adds or updates the entry of the key and evicts entries until the
+// cache fits its limits, the lock must be held.
+func (c *Cache) set(k string, v interface{}, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.changed(k)
+	size := c.config.Sizer(k, v)
+
+	if e, ok := c.items[k]; ok {
+		c.bytes += size - e.size
+		e.value, e.size, e.expires = v, size, expires
+		c.policy.access(e)
+	} else {
+		// Make room before adding, so the new entry is not picked by
+		// policies which favour older entries, such as LFU.
+		c.evict(1, size)
+
+		e := &cacheEntry{key: k, value: v, size: size, expires: expires}
+		c.items[k] = e
+		c.bytes += size
+		c.policy.add(e)
+	}
+
+	c.evict(0, 0)
+}
+
+// evict removes entries until the cache with the giving extra entries and
+// bytes fits its limits, the lock must be held.
+func (c *Cache) evict(items int, bytes int64) {
+	for (c.config.MaxItems > 0 && len(c.items)+items > c.config.MaxItems) || (c.config.MaxBytes > 0 && c.bytes+bytes > c.config.MaxBytes) {
+		victim := c.policy.victim(len(c.items) + items - 1)
+		if victim == nil {
+			return
+		}
+
+		c.remove(victim, Evicted)
+	}
+}
+
+// Stats returns the current counters of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+
+	stats := c.stats
+	stats.Items = len(c.items)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// EmitStats delivers the current stats to the giving metrics, see
+// CacheStats.Entry.
+func (c *Cache) EmitStats(m metrics.Metrics) error {
+	return m.Emit(c.Stats().Entry())
+}
+
+// Purge removes all expired entries.
+func (c *Cache) Purge() {
+	c.ml.Lock()
+	defer c.unlock()
+
+	now := time.Now()
+	for _, e := range c.items {
+		if e.expired(now) {
+			c.remove(e, Expired)
+		}
+	}
+}
+
+// Load returns the value of the key, loading it with the Loader of the config
+// if it is missing, see LoadWith.
+func (c *Cache) Load(k string) (interface{}, error) {
+	return c.LoadWith(k, c.config.Loader)
+}
+
+// LoadWith returns the value of the key, loading and setting it with the
+// giving loader if it is missing. Concurrent loads of the same key share a
+// single call of the loader. Failed loads are not cached, nor are loaded values
+// of keys set or removed while loading.
+func (c *Cache) LoadWith(k string, loader LoaderFunc) (interface{}, error) {
+	c.ml.Lock()
+
+	if e, ok := c.entry(k); ok {
+		c.stats.Hits++
+		c.policy.access(e)
+		value := e.value
+		c.unlock()
+		return value, nil
+	}
+
+	c.stats.Misses++
+
+	if call, ok := c.calls[k]; ok {
+		c.unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+
+	if loader == nil {
+		c.unlock()
+		return nil, ErrNoLoader
+	}
+
+	call := new(cacheCall)
+	call.wg.Add(1)
+	c.calls[k] = call
+	c.unlock()
+
+	c.load(k, loader, call)
+	return call.value, call.err
+}
+
+// load calls the loader for the key and sets the loaded value unless the key
+// was changed in the meantime. A panic of the loader is returned as the error
+// of the call, so waiting callers are always released.
+func (c *Cache) load(k string, loader LoaderFunc, call *cacheCall) {
+	defer call.wg.Done()
+
+	defer func() {
+		if ex := recover(); ex != nil {
+			call.value, call.err = nil, fmt.Errorf("ds: cache loader panicked for %q: %v", k, ex)
+		}
+
+		c.ml.Lock()
+		delete(c.calls, k)
+		c.stats.Loads++
+
+		if call.err != nil {
+			c.stats.LoadErrors++
+		} else if !call.stale {
+			c.set(k, call.value, c.config.TTL)
+		}
+		c.unlock()
+	}()
+
+	call.value, call.err = loader(k)
+}
+
+// SetWithTTL puts a specific key:value into the cache which expires after the
+// giving ttl, zero for no expiry.
+func (c *Cache) SetWithTTL(k string, v interface{}, ttl time.Duration) {
+	c.ml.Lock()
+	c.set(k, v, ttl)
+	c.unlock()
+}
+
+//==============================================================================
+
+// Clone makes a new cache with the same config and the live entries of this
+// cache, keeping their expiry.
+func (c *Cache) Clone() Maps {
+	col := NewCache(c.config)
+
+	c.ml.Lock()
+	defer c.unlock()
+
+	now := time.Now()
+	for k, e := range c.items {
+		if e.expired(now) {
+			continue
+		}
+
+		ne := &cacheEntry{key: k, value: e.value, size: e.size, expires: e.expires}
+		col.items[k] = ne
+		col.bytes += ne.size
+		col.policy.add(ne)
+	}
+
+	return col
+}
+
+// Len returns the total entries in the cache, including expired entries
+// which are not yet removed.
+func (c *Cache) Len() int {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+	return len(c.items)
+}
+
+// Remove deletes a key:value pair.
+func (c *Cache) Remove(k string) {
+	c.ml.Lock()
+	defer c.unlock()
+
+	c.changed(k)
+
+	if e, ok := c.items[k]; ok {
+		c.remove(e, Removed)
+	}
+}
+
+// Set puts a specific key:value into the cache using the TTL of the config.
+func (c *Cache) Set(k string, v interface{}) {
+	c.SetWithTTL(k, v, c.config.TTL)
+}
+
+// GetOrSet returns the existing value for the key if present, else it sets
+// and returns the giving value. The returned bool is true if the value was
+// found and false if set.
+func (c *Cache) GetOrSet(k string, v interface{}) (interface{}, bool) {
+	c.ml.Lock()
+	defer c.unlock()
+
+	if e, ok := c.entry(k); ok {
+		c.stats.Hits++
+		c.policy.access(e)
+		return e.value, true
+	}
+
+	c.stats.Misses++
+	c.set(k, v, c.config.TTL)
+	return v, false
+}
+
+// CompareAndSwap sets the new value for the key if it exists with a value
+// equal to old, returning true/false if it was swapped. The entry keeps its
+// expiry.
+func (c *Cache) CompareAndSwap(k string, old interface{}, new interface{}) bool {
+	c.ml.Lock()
+	defer c.unlock()
+
+	e, ok := c.entry(k)
+	if !ok || !equal(e.value, old) {
+		return false
+	}
+
+	expires := e.expires
+	c.set(k, new, 0)
+
+	if ne, ok := c.items[k]; ok {
+		ne.expires = expires
+	}
+
+	return true
+}
+
+// Copy copies the map into the cache using the TTL of the config.
+func (c *Cache) Copy(m map[string]interface{}) {
+	c.ml.Lock()
+	defer c.unlock()
+
+	for k, v := range m {
+		c.set(k, v, c.config.TTL)
+	}
+}
+
+// snapshot returns the live entries of the cache.
+func (c *Cache) snapshot() HashMap[string, interface{}] {
+	c.ml.Lock()
+	defer c.unlock()
+
+	now := time.Now()
+	col := make(HashMap[string, interface{}], len(c.items))
+	for k, e := range c.items {
+		if e.expired(now) {
+			c.remove(e, Expired)
+			continue
+		}
+
+		col[k] = e.value
+	}
+
+	return col
+}
+
+// Each iterates through a snapshot of the live entries in the cache, without
+// affecting their eviction order.
+func (c *Cache) Each(fx AnyMapFunc) {
+	c.snapshot().Each(fx)
+}
+
+// Range calls the function for each key:value of a snapshot of the live
+// entries in the cache until it returns false.
+func (c *Cache) Range(fx func(string, interface{}) bool) {
+	c.snapshot().Range(fx)
+}
+
+// Keys return the keys of the live entries in the cache.
+func (c *Cache) Keys() []string {
+	return c.snapshot().Keys()
+}
+
+// Get returns the value with the key.
+func (c *Cache) Get(k string) interface{} {
+	v, _ := c.Lookup(k)
+	return v
+}
+
+// Lookup returns the value with the key and true/false if it exists,
+// recording a hit or miss.
+func (c *Cache) Lookup(k string) (interface{}, bool) {
+	c.ml.Lock()
+	defer c.unlock()
+
+	e, ok := c.entry(k)
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.stats.Hits++
+	c.policy.access(e)
+	return e.value, true
+}
+
+// Has returns if a key exists, without affecting its eviction order or the
+// stats of the cache.
+func (c *Cache) Has(k string) bool {
+	c.ml.Lock()
+	defer c.unlock()
+
+	_, ok := c.entry(k)
+	return ok
+}
+
+// HasMatch checks if key and value exists and are matching.
+func (c *Cache) HasMatch(k string, v interface{}) bool {
+	c.ml.Lock()
+	defer c.unlock()
+
+	e, ok := c.entry(k)
+	return ok && equal(e.value, v)
+}
+
+// Clear removes all entries from the cache.
+func (c *Cache) Clear() {
+	c.ml.Lock()
+	defer c.unlock()
+
+	if c.config.OnEvict != nil {
+		for _, e := range c.items {
+			c.evicted = append(c.evicted, cacheEviction{key: e.key, value: e.value, reason: Removed})
+		}
+	}
+
+	for _, call := range c.calls {
+		call.stale = true
+	}
+
+	c.items = make(map[string]*cacheEntry)
+	c.policy.clear()
+	c.bytes = 0
+}
+
+//==============================================================================
+
+// EstimateSize returns an estimate of the bytes held by the giving value,
+// following pointers, slices, maps and struct fields.
+func EstimateSize(value interface{}) int64 {
+	if value == nil {
+		return 0
+	}
+
+	return estimateSize(reflect.ValueOf(value), make(map[uintptr]bool))
+}
+
+// estimateSize returns the size of the value and the data it refers to.
+func estimateSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	size := int64(v.Type().Size())
+
+	switch v.Kind() {
+	case reflect.String:
+		size += int64(v.Len())
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			break
+		}
+
+		if v.Kind() == reflect.Ptr {
+			if seen[v.Pointer()] {
+				break
+			}
+
+			seen[v.Pointer()] = true
+		}
+
+		size += estimateSize(v.Elem(), seen)
+
+	case reflect.Slice:
+		if v.IsNil() || seen[v.Pointer()] {
+			break
+		}
+
+		seen[v.Pointer()] = true
+		fallthrough
+
+	case reflect.Array:
+		elem := v.Type().Elem()
+		if isFlat(elem.Kind()) {
+			if v.Kind() == reflect.Slice {
+				size += int64(v.Cap()) * int64(elem.Size())
+			}
+
+			break
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			item := estimateSize(v.Index(i), seen)
+			if v.Kind() == reflect.Array {
+				item -= int64(elem.Size())
+			}
+
+			size += item
+		}
+
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			break
+		}
+
+		seen[v.Pointer()] = true
+
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), seen) + estimateSize(iter.Value(), seen)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += estimateSize(v.Field(i), seen) - int64(v.Field(i).Type().Size())
+		}
+	}
+
+	return size
+}
+
+// isFlat returns true/false if values of the kind hold no references.
+func isFlat(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,259 @@
+package ds_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/ds"
+	"github.com/influx6/faux/metrics"
+)
+
+var _ ds.Maps = ds.NewCache(ds.CacheConfig{})
+
+type metricsFunc func(metrics.Entry) error
+
+func (fn metricsFunc) Emit(e metrics.Entry) error {
+	return fn(e)
+}
+
+func cacheKeys(c *ds.Cache) []string {
+	keys := c.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCacheLRU(t *testing.T) {
+	var evicted []string
+
+	c := ds.NewCache(ds.CacheConfig{
+		MaxItems: 2,
+		OnEvict: func(key string, _ interface{}, reason ds.EvictReason) {
+			evicted = append(evicted, key+":"+reason.String())
+		},
+	})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+
+	if keys := cacheKeys(c); !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Fatalf("Should have evicted least recently used entry: %+q", keys)
+	}
+	t.Logf("Should have evicted least recently used entry")
+
+	c.Remove("a")
+
+	if !reflect.DeepEqual(evicted, []string{"b:evicted", "a:removed"}) {
+		t.Fatalf("Should have called OnEvict with reasons: %+q", evicted)
+	}
+	t.Logf("Should have called OnEvict with reasons")
+}
+
+func TestCacheLFU(t *testing.T) {
+	c := ds.NewCache(ds.CacheConfig{Policy: ds.LFU, MaxItems: 2})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("c", 3)
+
+	if keys := cacheKeys(c); !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Fatalf("Should have evicted least frequently used entry: %+q", keys)
+	}
+	t.Logf("Should have evicted least frequently used entry")
+}
+
+func TestCacheARC(t *testing.T) {
+	c := ds.NewCache(ds.CacheConfig{Policy: ds.ARC, MaxItems: 3})
+
+	c.Set("a", 1)
+	c.Get("a")
+
+	// A scan of entries seen once must not evict the frequently used entry.
+	for _, k := range []string{"b", "c", "d", "e", "f"} {
+		c.Set(k, 0)
+	}
+
+	if !c.Has("a") || c.Len() != 3 {
+		t.Fatalf("Should have kept frequently used entry during scan: %+q", cacheKeys(c))
+	}
+	t.Logf("Should have kept frequently used entry during scan")
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	c := ds.NewCache(ds.CacheConfig{
+		MaxBytes: 10,
+		Sizer: func(_ string, v interface{}) int64 {
+			return int64(len(v.(string)))
+		},
+	})
+
+	c.Set("a", "12345")
+	c.Set("b", "12345")
+	c.Set("c", "123")
+
+	if keys := cacheKeys(c); !reflect.DeepEqual(keys, []string{"b", "c"}) || c.Stats().Bytes != 8 {
+		t.Fatalf("Should have evicted entries above max bytes: %+q", keys)
+	}
+	t.Logf("Should have evicted entries above max bytes")
+}
+
+func TestCacheTTL(t *testing.T) {
+	var expired int32
+
+	c := ds.NewCache(ds.CacheConfig{
+		TTL: 20 * time.Millisecond,
+		OnEvict: func(_ string, _ interface{}, reason ds.EvictReason) {
+			if reason == ds.Expired {
+				atomic.AddInt32(&expired, 1)
+			}
+		},
+	})
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 0)
+	c.SetWithTTL("c", 3, time.Hour)
+
+	time.Sleep(40 * time.Millisecond)
+
+	if c.Has("a") || !c.Has("b") || !c.Has("c") {
+		t.Fatalf("Should have expired entry after its TTL: %+q", cacheKeys(c))
+	}
+	t.Logf("Should have expired entry after its TTL")
+
+	if atomic.LoadInt32(&expired) != 1 || c.Stats().Expirations != 1 {
+		t.Fatalf("Should have reported expired entry")
+	}
+	t.Logf("Should have reported expired entry")
+}
+
+func TestCacheLoad(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	c := ds.NewCache(ds.CacheConfig{
+		Loader: func(key string) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return key + "!", nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Load("a"); err != nil || v != "a!" {
+				t.Errorf("Should have loaded value: %v %v", v, err)
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Should have shared a single load: %d", calls)
+	}
+	t.Logf("Should have shared a single load")
+
+	if v, _ := c.Load("a"); v != "a!" || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Should have returned cached value")
+	}
+	t.Logf("Should have returned cached value")
+
+	failed := errors.New("failed")
+	if _, err := c.LoadWith("b", func(string) (interface{}, error) { return nil, failed }); err != failed || c.Has("b") {
+		t.Fatalf("Should have returned load error without caching: %v", err)
+	}
+	t.Logf("Should have returned load error without caching")
+
+	var stats ds.CacheStats
+	var emitted metrics.Entry
+
+	c.EmitStats(metricsFunc(func(e metrics.Entry) error {
+		emitted = e
+		return nil
+	}))
+
+	stats = c.Stats()
+	if stats.Hits != 1 || stats.Misses != 11 || stats.Loads != 2 || stats.LoadErrors != 1 {
+		t.Fatalf("Should have counted hits and misses: %+v", stats)
+	}
+	t.Logf("Should have counted hits and misses")
+
+	if hits, _ := emitted.Get("hits"); hits != int64(1) {
+		t.Fatalf("Should have emitted stats: %+v", emitted.Fields())
+	}
+	t.Logf("Should have emitted stats")
+}
+
+func TestCacheLoadPanic(t *testing.T) {
+	c := ds.NewCache(ds.CacheConfig{})
+
+	if _, err := c.LoadWith("a", func(string) (interface{}, error) { panic("boom") }); err == nil {
+		t.Fatalf("Should have returned loader panic as error")
+	}
+	t.Logf("Should have returned loader panic as error")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if v, err := c.LoadWith("a", func(string) (interface{}, error) { return 1, nil }); err != nil || v != 1 {
+			t.Errorf("Should have loaded value after panic: %v %v", v, err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Should have released key after loader panic")
+	}
+	t.Logf("Should have released key after loader panic")
+}
+
+func TestCacheLoadStale(t *testing.T) {
+	c := ds.NewCache(ds.CacheConfig{})
+
+	for _, change := range []func(){
+		func() { c.Set("k", "new") },
+		func() { c.Remove("k") },
+		func() { c.Clear() },
+	} {
+		c.Set("k", "new")
+		c.Remove("k")
+
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			c.LoadWith("k", func(string) (interface{}, error) {
+				close(started)
+				<-release
+				return "old", nil
+			})
+		}()
+
+		<-started
+		change()
+		expected, exists := c.Lookup("k")
+		close(release)
+		<-done
+
+		if v, ok := c.Lookup("k"); v != expected || ok != exists {
+			t.Fatalf("Should have kept change made while loading: %v", v)
+		}
+	}
+	t.Logf("Should have kept changes made while loading")
+}
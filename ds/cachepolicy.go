@@ -0,0 +1,285 @@
+package ds
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// evictor defines the bookkeeping of an EvictionPolicy used by the Cache to
+// pick the next entry to evict. The Cache calls it while holding its lock.
+type evictor interface {
+	// add records a new entry.
+	add(e *cacheEntry)
+
+	// access records a hit or update of an existing entry.
+	access(e *cacheEntry)
+
+	// remove drops an entry, where evicted is true if it was removed to free
+	// space, rather than being deleted or expired.
+	remove(e *cacheEntry, evicted bool)
+
+	// victim returns the next entry to evict, where size is the number of
+	// entries the cache holds after the eviction.
+	victim(size int) *cacheEntry
+
+	// clear drops all entries.
+	clear()
+}
+
+// newEvictor returns the evictor of the giving policy.
+func newEvictor(policy EvictionPolicy) evictor {
+	switch policy {
+	case LFU:
+		return &lfuEvictor{}
+	case ARC:
+		return newARCEvictor()
+	default:
+		return &lruEvictor{items: list.New()}
+	}
+}
+
+//==============================================================================
+
+// lruEvictor evicts the least recently used entry.
+type lruEvictor struct {
+	items *list.List
+}
+
+func (l *lruEvictor) add(e *cacheEntry) {
+	e.elem = l.items.PushFront(e)
+}
+
+func (l *lruEvictor) access(e *cacheEntry) {
+	l.items.MoveToFront(e.elem)
+}
+
+func (l *lruEvictor) remove(e *cacheEntry, _ bool) {
+	l.items.Remove(e.elem)
+	e.elem = nil
+}
+
+func (l *lruEvictor) victim(_ int) *cacheEntry {
+	if back := l.items.Back(); back != nil {
+		return back.Value.(*cacheEntry)
+	}
+
+	return nil
+}
+
+func (l *lruEvictor) clear() {
+	l.items.Init()
+}
+
+//==============================================================================
+
+// lfuEvictor evicts the least frequently used entry, picking the least
+// recently used among entries with the same frequency.
+type lfuEvictor struct {
+	items lfuHeap
+	tick  uint64
+}
+
+func (l *lfuEvictor) add(e *cacheEntry) {
+	l.tick++
+	e.freq, e.tick = 1, l.tick
+	heap.Push(&l.items, e)
+}
+
+func (l *lfuEvictor) access(e *cacheEntry) {
+	l.tick++
+	e.freq++
+	e.tick = l.tick
+	heap.Fix(&l.items, e.index)
+}
+
+func (l *lfuEvictor) remove(e *cacheEntry, _ bool) {
+	heap.Remove(&l.items, e.index)
+}
+
+func (l *lfuEvictor) victim(_ int) *cacheEntry {
+	if len(l.items) == 0 {
+		return nil
+	}
+
+	return l.items[0]
+}
+
+func (l *lfuEvictor) clear() {
+	l.items = nil
+}
+
+// lfuHeap implements heap.Interface ordering entries by frequency and then
+// by their last access.
+type lfuHeap []*cacheEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+
+	return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*cacheEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	e.index = -1
+	return e
+}
+
+//==============================================================================
+
+// arcEvictor implements the Adaptive Replacement Cache policy, which balances
+// between recently used entries seen once (t1) and frequently used entries
+// seen at least twice (t2). The keys of evicted entries are kept in the ghost
+// lists b1 and b2, and new entries found in them shift the target size of t1.
+type arcEvictor struct {
+	t1, t2 *list.List
+	b1, b2 *list.List
+	ghosts map[string]*list.Element
+
+	// target sets the adaptive target size of t1.
+	target int
+
+	// capacity sets the number of entries tracked by the ghost lists, which
+	// follows the size of the cache at the last eviction.
+	capacity int
+}
+
+func newARCEvictor() *arcEvictor {
+	return &arcEvictor{
+		t1:     list.New(),
+		t2:     list.New(),
+		b1:     list.New(),
+		b2:     list.New(),
+		ghosts: make(map[string]*list.Element),
+	}
+}
+
+func (a *arcEvictor) add(e *cacheEntry) {
+	ghost, ok := a.ghosts[e.key]
+	if !ok {
+		e.elem, e.frequent = a.t1.PushFront(e), false
+		return
+	}
+
+	// A ghost hit shows the list it was evicted from was too small.
+	if ghost.Value.(ghostKey).recent {
+		a.target += ratio(a.b2.Len(), a.b1.Len())
+		if a.target > a.capacity {
+			a.target = a.capacity
+		}
+
+		a.b1.Remove(ghost)
+	} else {
+		a.target -= ratio(a.b1.Len(), a.b2.Len())
+		if a.target < 0 {
+			a.target = 0
+		}
+
+		a.b2.Remove(ghost)
+	}
+
+	delete(a.ghosts, e.key)
+	e.elem, e.frequent = a.t2.PushFront(e), true
+}
+
+func (a *arcEvictor) access(e *cacheEntry) {
+	a.listOf(e).Remove(e.elem)
+	e.elem, e.frequent = a.t2.PushFront(e), true
+}
+
+func (a *arcEvictor) remove(e *cacheEntry, evicted bool) {
+	a.listOf(e).Remove(e.elem)
+	e.elem = nil
+
+	if !evicted {
+		return
+	}
+
+	ghosts := a.b1
+	if e.frequent {
+		ghosts = a.b2
+	}
+
+	a.ghosts[e.key] = ghosts.PushFront(ghostKey{key: e.key, recent: !e.frequent})
+
+	// Trim the ghost lists to the capacity of the cache.
+	for a.b1.Len()+a.b2.Len() > a.capacity {
+		ghosts := a.b2
+		if a.b1.Len() > a.target || a.b2.Len() == 0 {
+			ghosts = a.b1
+		}
+
+		back := ghosts.Back()
+		delete(a.ghosts, back.Value.(ghostKey).key)
+		ghosts.Remove(back)
+	}
+}
+
+func (a *arcEvictor) victim(size int) *cacheEntry {
+	if size > a.capacity {
+		a.capacity = size
+	}
+
+	items := a.t2
+	if a.t1.Len() != 0 && (a.t1.Len() > a.target || a.t2.Len() == 0) {
+		items = a.t1
+	}
+
+	if back := items.Back(); back != nil {
+		return back.Value.(*cacheEntry)
+	}
+
+	return nil
+}
+
+func (a *arcEvictor) clear() {
+	a.t1.Init()
+	a.t2.Init()
+	a.b1.Init()
+	a.b2.Init()
+	a.ghosts = make(map[string]*list.Element)
+	a.target = 0
+}
+
+// listOf returns the resident list holding the entry.
+func (a *arcEvictor) listOf(e *cacheEntry) *list.List {
+	if e.frequent {
+		return a.t2
+	}
+
+	return a.t1
+}
+
+// ghostKey defines the key of an evicted entry held by a ghost list.
+type ghostKey struct {
+	key    string
+	recent bool
+}
+
+// ratio returns the step by which ARC adapts its target, which is the size of
+// the other ghost list relative to the hit one, but at least 1.
+func ratio(other, hit int) int {
+	if hit == 0 || other < hit {
+		return 1
+	}
+
+	return other / hit
+}
@@ -0,0 +1,155 @@
+package ds
+
+import "sync"
+
+// ConcurrentMap provides a mutex controlled Map, safe for concurrent use.
+type ConcurrentMap[K comparable, V any] struct {
+	rw sync.RWMutex
+	c  Map[K, V]
+}
+
+// NewConcurrentMap returns a new ConcurrentMap guarding the giving Map, using
+// a new HashMap if it is nil.
+func NewConcurrentMap[K comparable, V any](m Map[K, V]) *ConcurrentMap[K, V] {
+	if m == nil {
+		m = NewHashMap[K, V]()
+	}
+
+	so := ConcurrentMap[K, V]{c: m}
+	return &so
+}
+
+// Clone makes a new clone of this map.
+func (c *ConcurrentMap[K, V]) Clone() Map[K, V] {
+	var co Map[K, V]
+
+	c.rw.RLock()
+	co = c.c.Clone()
+	c.rw.RUnlock()
+
+	return NewConcurrentMap(co)
+}
+
+// Len returns the total items in the map.
+func (c *ConcurrentMap[K, V]) Len() int {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	return c.c.Len()
+}
+
+// Remove deletes a key:value pair.
+func (c *ConcurrentMap[K, V]) Remove(k K) {
+	c.rw.Lock()
+	c.c.Remove(k)
+	c.rw.Unlock()
+}
+
+// Set puts a specific key:value into the map.
+func (c *ConcurrentMap[K, V]) Set(k K, v V) {
+	c.rw.Lock()
+	c.c.Set(k, v)
+	c.rw.Unlock()
+}
+
+// GetOrSet returns the existing value for the key if present, else it sets
+// and returns the giving value. The returned bool is true if the value was
+// found and false if set.
+func (c *ConcurrentMap[K, V]) GetOrSet(k K, v V) (V, bool) {
+	c.rw.Lock()
+	defer c.rw.Unlock()
+	return c.c.GetOrSet(k, v)
+}
+
+// CompareAndSwap sets the new value for the key if it exists with a value
+// equal to old, returning true/false if it was swapped.
+func (c *ConcurrentMap[K, V]) CompareAndSwap(k K, old V, new V) bool {
+	c.rw.Lock()
+	defer c.rw.Unlock()
+	return c.c.CompareAndSwap(k, old, new)
+}
+
+// Copy copies the map into the map.
+func (c *ConcurrentMap[K, V]) Copy(m map[K]V) {
+	c.rw.Lock()
+	c.c.Copy(m)
+	c.rw.Unlock()
+}
+
+// Each iterates through all items in the map, holding the read lock, so the
+// function must not modify the map, see Range.
+func (c *ConcurrentMap[K, V]) Each(fx EachFunc[K, V]) {
+	c.rw.RLock()
+	c.c.Each(fx)
+	c.rw.RUnlock()
+}
+
+// Range calls the function for each key:value of a snapshot of the map until
+// it returns false. The lock is not held while the function runs, so it may
+// modify the map.
+func (c *ConcurrentMap[K, V]) Range(fx func(K, V) bool) {
+	c.rw.RLock()
+	keys := make([]K, 0, c.c.Len())
+	values := make([]V, 0, c.c.Len())
+	c.c.Range(func(k K, v V) bool {
+		keys = append(keys, k)
+		values = append(values, v)
+		return true
+	})
+	c.rw.RUnlock()
+
+	for index, k := range keys {
+		if !fx(k, values[index]) {
+			return
+		}
+	}
+}
+
+// Keys return the keys of the map.
+func (c *ConcurrentMap[K, V]) Keys() []K {
+	var keys []K
+	c.rw.RLock()
+	keys = c.c.Keys()
+	c.rw.RUnlock()
+	return keys
+}
+
+// Get returns the value with the key.
+func (c *ConcurrentMap[K, V]) Get(k K) V {
+	var v V
+	c.rw.RLock()
+	v = c.c.Get(k)
+	c.rw.RUnlock()
+	return v
+}
+
+// Lookup returns the value with the key and true/false if it exists.
+func (c *ConcurrentMap[K, V]) Lookup(k K) (V, bool) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	return c.c.Lookup(k)
+}
+
+// Has returns if a key exists.
+func (c *ConcurrentMap[K, V]) Has(k K) bool {
+	var ok bool
+	c.rw.RLock()
+	ok = c.c.Has(k)
+	c.rw.RUnlock()
+	return ok
+}
+
+// HasMatch checks if key and value exists and are matching.
+func (c *ConcurrentMap[K, V]) HasMatch(k K, v V) bool {
+	var ok bool
+	c.rw.RLock()
+	ok = c.c.HasMatch(k, v)
+	c.rw.RUnlock()
+	return ok
+}
+
+// Clear clears the map.
+func (c *ConcurrentMap[K, V]) Clear() {
+	c.rw.Lock()
+	c.c.Clear()
+	c.rw.Unlock()
+}
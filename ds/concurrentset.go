@@ -0,0 +1,125 @@
+package ds
+
+import "sync"
+
+// ConcurrentSet provides a mutex controlled Set, safe for concurrent use.
+type ConcurrentSet[T comparable] struct {
+	rw sync.RWMutex
+	c  Set[T]
+}
+
+// NewConcurrentSet returns a new ConcurrentSet guarding the giving Set, using
+// a new HashSet if it is nil.
+func NewConcurrentSet[T comparable](m Set[T]) *ConcurrentSet[T] {
+	if m == nil {
+		m = NewHashSet[T]()
+	}
+
+	so := ConcurrentSet[T]{c: m}
+	return &so
+}
+
+// Clone makes a new clone of this set.
+func (c *ConcurrentSet[T]) Clone() Set[T] {
+	var co Set[T]
+
+	c.rw.RLock()
+	co = c.c.Clone()
+	c.rw.RUnlock()
+
+	return NewConcurrentSet(co)
+}
+
+// Len returns the total items in the set.
+func (c *ConcurrentSet[T]) Len() int {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	return c.c.Len()
+}
+
+// Remove deletes an item from the set.
+func (c *ConcurrentSet[T]) Remove(k T) {
+	c.rw.Lock()
+	c.c.Remove(k)
+	c.rw.Unlock()
+}
+
+// Set puts a specific item into the set.
+func (c *ConcurrentSet[T]) Set(k T) {
+	c.rw.Lock()
+	c.c.Set(k)
+	c.rw.Unlock()
+}
+
+// Copy copies the items with a true value in the map into the set.
+func (c *ConcurrentSet[T]) Copy(m map[T]bool) {
+	c.rw.Lock()
+	c.c.Copy(m)
+	c.rw.Unlock()
+}
+
+// Each iterates through all items in the set, holding the read lock, so the
+// function must not modify the set, see Range.
+func (c *ConcurrentSet[T]) Each(fx SetFunc[T]) {
+	c.rw.RLock()
+	c.c.Each(fx)
+	c.rw.RUnlock()
+}
+
+// Range calls the function for each item of a snapshot of the set until it
+// returns false. The lock is not held while the function runs, so it may
+// modify the set.
+func (c *ConcurrentSet[T]) Range(fx func(T) bool) {
+	for _, k := range c.Keys() {
+		if !fx(k) {
+			return
+		}
+	}
+}
+
+// Keys return the items of the set.
+func (c *ConcurrentSet[T]) Keys() []T {
+	var keys []T
+	c.rw.RLock()
+	keys = c.c.Keys()
+	c.rw.RUnlock()
+	return keys
+}
+
+// Has returns if an item exists.
+func (c *ConcurrentSet[T]) Has(k T) bool {
+	var ok bool
+	c.rw.RLock()
+	ok = c.c.Has(k)
+	c.rw.RUnlock()
+	return ok
+}
+
+// Union returns a new ConcurrentSet with the items found in either sets.
+func (c *ConcurrentSet[T]) Union(other Set[T]) Set[T] {
+	return NewConcurrentSet(c.snapshot().Union(other))
+}
+
+// Intersection returns a new ConcurrentSet with the items found in both sets.
+func (c *ConcurrentSet[T]) Intersection(other Set[T]) Set[T] {
+	return NewConcurrentSet(c.snapshot().Intersection(other))
+}
+
+// Difference returns a new ConcurrentSet with the items not found in the
+// other set.
+func (c *ConcurrentSet[T]) Difference(other Set[T]) Set[T] {
+	return NewConcurrentSet(c.snapshot().Difference(other))
+}
+
+// snapshot returns a HashSet with the current items of the set, so set
+// operations do not hold the lock while reading the other set.
+func (c *ConcurrentSet[T]) snapshot() HashSet[T] {
+	return NewHashSet(c.Keys()...)
+}
+
+// Clear clears the set.
+func (c *ConcurrentSet[T]) Clear() {
+	c.rw.Lock()
+	c.c.Clear()
+	c.rw.Unlock()
+}
@@ -1,46 +1,65 @@
 package ds
 
-// Maps define a set of method rules for maps of the string key types
-type Maps interface {
+// Map define a set of method rules for maps of any comparable key type.
+type Map[K comparable, V any] interface {
 	Clear()
-	HasMatch(k string, v interface{}) bool
-	Each(f AnyMapFunc)
-	Keys() []string
-	Copy(map[string]interface{})
-	Has(string) bool
-	Get(string) interface{}
-	Remove(string)
-	Set(k string, v interface{})
-	Clone() Maps
+	Len() int
+	HasMatch(k K, v V) bool
+	Each(f EachFunc[K, V])
+	Range(f func(K, V) bool)
+	Keys() []K
+	Copy(map[K]V)
+	Has(K) bool
+	Get(K) V
+	Lookup(K) (V, bool)
+	GetOrSet(k K, v V) (V, bool)
+	CompareAndSwap(k K, old V, new V) bool
+	Remove(K)
+	Set(k K, v V)
+	Clone() Map[K, V]
 }
 
-// Stores define a set of method rules for maps of the string key types
-type Stores interface {
+// EachFunc defines the type of the Map.Each rule, which receives the value,
+// its key and a function to stop the iteration.
+type EachFunc[K comparable, V any] func(V, K, func())
+
+// Set define a set of method rules for sets of any comparable item type.
+type Set[T comparable] interface {
 	Clear()
-	HasMatch(k string, v string) bool
-	Each(f StoreFunc)
-	Keys() []string
-	Copy(map[string]string)
-	Has(string) bool
-	Get(string) string
-	Remove(string)
-	Set(k string, v string)
-	Clone() Stores
+	Len() int
+	Each(f SetFunc[T])
+	Range(f func(T) bool)
+	Keys() []T
+	Copy(map[T]bool)
+	Has(T) bool
+	Remove(T)
+	Set(T)
+	Clone() Set[T]
+	Union(Set[T]) Set[T]
+	Intersection(Set[T]) Set[T]
+	Difference(Set[T]) Set[T]
 }
 
+// SetFunc defines the type of the Set.Each rule, which receives the item and
+// a function to stop the iteration.
+type SetFunc[T comparable] func(T, func())
+
+// Maps define a set of method rules for maps of the string key types
+type Maps = Map[string, interface{}]
+
+// Stores define a set of method rules for maps of the string key types
+type Stores = Map[string, string]
+
 // TruthTable define a set of method rules truth tables.
-type TruthTable interface {
-	Clear()
-	Each(f BoolFunc)
-	Keys() []string
-	Copy(map[string]bool)
-	Has(string) bool
-	Remove(string)
-	Set(k string)
-	Clone() TruthTable
-}
+type TruthTable = Set[string]
 
 // NewTruthTable returns a new instance of TruthTable.
 func NewTruthTable() TruthTable {
 	return NewTruthMap(NewBoolStore())
 }
+
+// equal returns true/false if the values are equal, it panics like the ==
+// operator if their dynamic types are not comparable.
+func equal[V any](a, b V) bool {
+	return interface{}(a) == interface{}(b)
+}
@@ -0,0 +1,151 @@
+package ds_test
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/influx6/faux/ds"
+)
+
+var (
+	_ ds.Maps       = ds.NewAnyMap()
+	_ ds.Maps       = ds.NewLockMap(ds.NewAnyMap())
+	_ ds.Stores     = ds.NewStringStore()
+	_ ds.Stores     = ds.NewLockStore(ds.NewStringStore())
+	_ ds.TruthTable = ds.NewBoolStore()
+	_ ds.TruthTable = ds.NewTruthTable()
+)
+
+func TestMap(t *testing.T) {
+	maps := []ds.Map[string, int]{
+		ds.NewHashMap[string, int](),
+		ds.NewConcurrentMap[string, int](nil),
+	}
+
+	for _, m := range maps {
+		if v, loaded := m.GetOrSet("a", 1); loaded || v != 1 {
+			t.Fatalf("Should have set missing key: %d %t", v, loaded)
+		}
+		t.Logf("Should have set missing key")
+
+		if v, loaded := m.GetOrSet("a", 2); !loaded || v != 1 {
+			t.Fatalf("Should have returned existing value: %d %t", v, loaded)
+		}
+		t.Logf("Should have returned existing value")
+
+		if m.CompareAndSwap("a", 2, 3) {
+			t.Fatalf("Should have failed to swap unmatched value")
+		}
+		t.Logf("Should have failed to swap unmatched value")
+
+		if !m.CompareAndSwap("a", 1, 3) || m.Get("a") != 3 {
+			t.Fatalf("Should have swapped matched value")
+		}
+		t.Logf("Should have swapped matched value")
+
+		if m.CompareAndSwap("b", 0, 1) || m.Has("b") {
+			t.Fatalf("Should have failed to swap missing key")
+		}
+		t.Logf("Should have failed to swap missing key")
+
+		m.Copy(map[string]int{"b": 2, "c": 3})
+
+		var total int
+		m.Range(func(k string, v int) bool {
+			total += v
+			return true
+		})
+
+		if total != 8 || m.Len() != 3 {
+			t.Fatalf("Should have ranged through all items: %d", total)
+		}
+		t.Logf("Should have ranged through all items")
+
+		clone := m.Clone()
+		m.Clear()
+
+		if m.Len() != 0 || clone.Len() != 3 {
+			t.Fatalf("Should have cleared map without clone")
+		}
+		t.Logf("Should have cleared map without clone")
+	}
+}
+
+func TestConcurrentMapRange(t *testing.T) {
+	m := ds.NewConcurrentMap[int, int](nil)
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+
+	m.Range(func(k int, v int) bool {
+		m.Remove(k)
+		return true
+	})
+
+	if m.Len() != 0 {
+		t.Fatalf("Should have allowed modifications during Range")
+	}
+	t.Logf("Should have allowed modifications during Range")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					v, _ := m.GetOrSet(0, 0)
+					if m.CompareAndSwap(0, v, v+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m.Get(0) != 800 {
+		t.Fatalf("Should have counted with CompareAndSwap: %d", m.Get(0))
+	}
+	t.Logf("Should have counted with CompareAndSwap")
+}
+
+func sorted(s ds.Set[int]) []int {
+	items := s.Keys()
+	sort.Ints(items)
+	return items
+}
+
+func TestSet(t *testing.T) {
+	sets := [][2]ds.Set[int]{
+		{ds.NewHashSet(1, 2, 3), ds.NewHashSet(2, 3, 4)},
+		{ds.NewConcurrentSet[int](ds.NewHashSet(1, 2, 3)), ds.NewConcurrentSet[int](ds.NewHashSet(2, 3, 4))},
+		{ds.NewConcurrentSet[int](ds.NewHashSet(1, 2, 3)), ds.NewHashSet(2, 3, 4)},
+	}
+
+	for _, pair := range sets {
+		a, b := pair[0], pair[1]
+
+		if items := sorted(a.Union(b)); !reflect.DeepEqual(items, []int{1, 2, 3, 4}) {
+			t.Fatalf("Should have created union: %v", items)
+		}
+		t.Logf("Should have created union")
+
+		if items := sorted(a.Intersection(b)); !reflect.DeepEqual(items, []int{2, 3}) {
+			t.Fatalf("Should have created intersection: %v", items)
+		}
+		t.Logf("Should have created intersection")
+
+		if items := sorted(a.Difference(b)); !reflect.DeepEqual(items, []int{1}) {
+			t.Fatalf("Should have created difference: %v", items)
+		}
+		t.Logf("Should have created difference")
+
+		if a.Len() != 3 || b.Len() != 3 {
+			t.Fatalf("Should have left sets unchanged")
+		}
+		t.Logf("Should have left sets unchanged")
+	}
+}
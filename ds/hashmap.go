@@ -0,0 +1,127 @@
+package ds
+
+// HashMap defines a plain Map backed by a go map, it is not safe for
+// concurrent use, see ConcurrentMap.
+type HashMap[K comparable, V any] map[K]V
+
+// NewHashMap returns a new HashMap instance.
+func NewHashMap[K comparable, V any]() HashMap[K, V] {
+	return make(HashMap[K, V])
+}
+
+// Clone makes a new clone of this HashMap.
+func (c HashMap[K, V]) Clone() Map[K, V] {
+	col := make(HashMap[K, V], len(c))
+	col.Copy(c)
+	return col
+}
+
+// Len returns the total items in the HashMap.
+func (c HashMap[K, V]) Len() int {
+	return len(c)
+}
+
+// Remove deletes a key:value pair.
+func (c HashMap[K, V]) Remove(k K) {
+	delete(c, k)
+}
+
+// Keys return the keys of the HashMap.
+func (c HashMap[K, V]) Keys() []K {
+	var keys []K
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Get returns the value with the key.
+func (c HashMap[K, V]) Get(k K) V {
+	return c[k]
+}
+
+// Lookup returns the value with the key and true/false if it exists.
+func (c HashMap[K, V]) Lookup(k K) (V, bool) {
+	v, ok := c[k]
+	return v, ok
+}
+
+// Has returns if a key exists.
+func (c HashMap[K, V]) Has(k K) bool {
+	_, ok := c[k]
+	return ok
+}
+
+// HasMatch checks if key and value exists and are matching.
+func (c HashMap[K, V]) HasMatch(k K, v V) bool {
+	if cur, ok := c[k]; ok {
+		return equal(cur, v)
+	}
+	return false
+}
+
+// Set puts a specific key:value into the HashMap.
+func (c HashMap[K, V]) Set(k K, v V) {
+	c[k] = v
+}
+
+// GetOrSet returns the existing value for the key if present, else it sets
+// and returns the giving value. The returned bool is true if the value was
+// found and false if set.
+func (c HashMap[K, V]) GetOrSet(k K, v V) (V, bool) {
+	if cur, ok := c[k]; ok {
+		return cur, true
+	}
+
+	c[k] = v
+	return v, false
+}
+
+// CompareAndSwap sets the new value for the key if it exists with a value
+// equal to old, returning true/false if it was swapped.
+func (c HashMap[K, V]) CompareAndSwap(k K, old V, new V) bool {
+	if cur, ok := c[k]; !ok || !equal(cur, old) {
+		return false
+	}
+
+	c[k] = new
+	return true
+}
+
+// Copy copies the map into the HashMap.
+func (c HashMap[K, V]) Copy(m map[K]V) {
+	for k, v := range m {
+		c[k] = v
+	}
+}
+
+// Each iterates through all items in the HashMap.
+func (c HashMap[K, V]) Each(fx EachFunc[K, V]) {
+	var state bool
+	for k, v := range c {
+		if state {
+			break
+		}
+
+		fx(v, k, func() {
+			state = true
+		})
+	}
+}
+
+// Range calls the function for each key:value in the HashMap until it returns
+// false.
+func (c HashMap[K, V]) Range(fx func(K, V) bool) {
+	for k, v := range c {
+		if !fx(k, v) {
+			return
+		}
+	}
+}
+
+// Clear clears the HashMap.
+func (c HashMap[K, V]) Clear() {
+	for k := range c {
+		delete(c, k)
+	}
+}
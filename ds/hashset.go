@@ -0,0 +1,127 @@
+package ds
+
+// HashSet defines a plain Set backed by a go map, where every key of the map
+// is a member of the set. It is not safe for concurrent use, see
+// ConcurrentSet.
+type HashSet[T comparable] map[T]bool
+
+// NewHashSet returns a new HashSet instance holding the giving items.
+func NewHashSet[T comparable](items ...T) HashSet[T] {
+	col := make(HashSet[T], len(items))
+	for _, item := range items {
+		col[item] = true
+	}
+	return col
+}
+
+// Clone makes a new clone of this HashSet.
+func (c HashSet[T]) Clone() Set[T] {
+	col := make(HashSet[T], len(c))
+	for k := range c {
+		col[k] = true
+	}
+	return col
+}
+
+// Len returns the total items in the HashSet.
+func (c HashSet[T]) Len() int {
+	return len(c)
+}
+
+// Remove deletes an item from the HashSet.
+func (c HashSet[T]) Remove(k T) {
+	delete(c, k)
+}
+
+// Keys return the items of the HashSet.
+func (c HashSet[T]) Keys() []T {
+	var keys []T
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Has returns if an item exists.
+func (c HashSet[T]) Has(k T) bool {
+	_, ok := c[k]
+	return ok
+}
+
+// Set puts a specific item into the HashSet.
+func (c HashSet[T]) Set(k T) {
+	c[k] = true
+}
+
+// Copy copies the items with a true value in the map into the HashSet.
+func (c HashSet[T]) Copy(m map[T]bool) {
+	for k, ok := range m {
+		if ok {
+			c[k] = true
+		}
+	}
+}
+
+// Each iterates through all items in the HashSet.
+func (c HashSet[T]) Each(fx SetFunc[T]) {
+	var state bool
+	for k := range c {
+		if state {
+			break
+		}
+
+		fx(k, func() {
+			state = true
+		})
+	}
+}
+
+// Range calls the function for each item in the HashSet until it returns
+// false.
+func (c HashSet[T]) Range(fx func(T) bool) {
+	for k := range c {
+		if !fx(k) {
+			return
+		}
+	}
+}
+
+// Union returns a new HashSet with the items found in either sets.
+func (c HashSet[T]) Union(other Set[T]) Set[T] {
+	col := c.Clone().(HashSet[T])
+	other.Range(func(k T) bool {
+		col[k] = true
+		return true
+	})
+	return col
+}
+
+// Intersection returns a new HashSet with the items found in both sets.
+func (c HashSet[T]) Intersection(other Set[T]) Set[T] {
+	col := make(HashSet[T])
+	other.Range(func(k T) bool {
+		if c.Has(k) {
+			col[k] = true
+		}
+		return true
+	})
+	return col
+}
+
+// Difference returns a new HashSet with the items not found in the other
+// set.
+func (c HashSet[T]) Difference(other Set[T]) Set[T] {
+	col := c.Clone().(HashSet[T])
+	other.Range(func(k T) bool {
+		delete(col, k)
+		return true
+	})
+	return col
+}
+
+// Clear clears the HashSet.
+func (c HashSet[T]) Clear() {
+	for k := range c {
+		delete(c, k)
+	}
+}
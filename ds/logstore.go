@@ -0,0 +1,914 @@
+package ds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contains errors returned by the LogStore.
+var (
+	// ErrStoreClosed is returned when writing into a closed LogStore.
+	ErrStoreClosed = errors.New("ds: log store is closed")
+
+	// ErrCorruptRecord is returned when a record fails its checksum or can not
+	// be decoded.
+	ErrCorruptRecord = errors.New("ds: corrupt log store record")
+
+	// errTornRecord is returned for a record cut short by the end of the data,
+	// as left by a crash during a write.
+	errTornRecord = errors.New("ds: torn log store record")
+)
+
+// contains the names of the files of a LogStore within its directory.
+const (
+	LogFileName      = "store.log"
+	SnapshotFileName = "store.snapshot"
+)
+
+// DefaultCompactAfter defines the number of operations written to the log
+// after which a LogStore compacts it when none is provided.
+const DefaultCompactAfter = 1 << 12
+
+// LogStoreConfig defines the settings used by OpenLogStore.
+type LogStoreConfig struct {
+	// Dir sets the directory holding the log and snapshot files, it is
+	// created if missing.
+	Dir string
+
+	// SyncWrites sets every write to be synced to disk before returning,
+	// else a crash of the machine may lose the latest writes.
+	SyncWrites bool
+
+	// CompactAfter sets the number of operations written to the log after
+	// which it is compacted into a snapshot. It defaults to
+	// DefaultCompactAfter, where a negative value disables it.
+	CompactAfter int
+
+	// CompactInterval sets the period at which the log is compacted if it has
+	// new operations, zero to disable.
+	CompactInterval time.Duration
+}
+
+// StoreChange defines a change delivered by LogStore.Watch.
+type StoreChange struct {
+	Seq     uint64
+	Key     string
+	Value   string
+	Removed bool
+}
+
+// Batch defines a list of operations written atomically by LogStore.Write.
+type Batch struct {
+	ops []logOp
+}
+
+// NewBatch returns a new Batch instance.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Set adds the setting of the key:value into the batch.
+func (b *Batch) Set(k string, v string) *Batch {
+	b.ops = append(b.ops, logOp{key: k, value: v})
+	return b
+}
+
+// Remove adds the removal of the key into the batch.
+func (b *Batch) Remove(k string) *Batch {
+	b.ops = append(b.ops, logOp{key: k, removed: true})
+	return b
+}
+
+// Len returns the total operations in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// LogStore provides a durable Stores backed by an append-only log in a
+// directory. All items are held in memory and every write is appended to the
+// log as a single checksummed record, so batches are atomic. The log is
+// compacted into a snapshot file once it grows past CompactAfter operations.
+//
+// When opened, the snapshot is loaded and the log replayed on top of it. A
+// record torn by a crash at the end of the log is dropped, leaving the store at
+// its last complete write. A corrupt record followed by other records, such as
+// one whose length runs past a later record, fails the open with
+// ErrCorruptRecord, leaving the log intact.
+//
+// The methods of the Stores interface can not return errors, their last error
+// is returned by Err. Use Write for error checked writes.
+type LogStore struct {
+	config LogStoreConfig
+
+	ml     sync.RWMutex
+	items  map[string]string
+	file   *os.File
+	size   int64
+	seq    uint64
+	ops    int
+	err    error
+	broken error
+	closed bool
+
+	wl       sync.Mutex
+	watchers map[*storeWatcher]bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OpenLogStore returns a LogStore for the directory of the config, recovering
+// the items written by previous instances.
+func OpenLogStore(config LogStoreConfig) (*LogStore, error) {
+	if config.CompactAfter == 0 {
+		config.CompactAfter = DefaultCompactAfter
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &LogStore{
+		config:   config,
+		items:    make(map[string]string),
+		watchers: make(map[*storeWatcher]bool),
+		done:     make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	if config.CompactInterval > 0 {
+		s.wg.Add(1)
+		go s.compactor()
+	}
+
+	return s, nil
+}
+
+// recover loads the snapshot and replays the log.
+func (s *LogStore) recover() error {
+	snapshot, err := os.Open(filepath.Join(s.config.Dir, SnapshotFileName))
+	switch {
+	case err == nil:
+		defer snapshot.Close()
+
+		info, err := snapshot.Stat()
+		if err != nil {
+			return err
+		}
+
+		rec, _, err := readRecord(bufio.NewReader(snapshot), info.Size())
+		if err != nil {
+			return fmt.Errorf("ds: failed to read log store snapshot: %s", err)
+		}
+
+		s.apply(rec)
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.config.Dir, LogFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+
+	var offset int64
+	for {
+		rec, n, err := readRecord(reader, info.Size()-offset)
+		if err == io.EOF {
+			break
+		}
+
+		// A record which fails its checksum as the last of the log is torn
+		// like a short one, but one followed by other records is corrupt and
+		// truncating it would drop them too.
+		if err == ErrCorruptRecord && offset+n < info.Size() {
+			file.Close()
+			return fmt.Errorf("%w at offset %d of %s", err, offset, LogFileName)
+		}
+
+		// A record running past the end of the log is only torn if no
+		// complete record follows it, else its length is corrupt.
+		if err == errTornRecord {
+			found, ferr := recordAfter(file, offset, info.Size())
+			if ferr != nil {
+				file.Close()
+				return ferr
+			}
+
+			if found {
+				file.Close()
+				return fmt.Errorf("%w at offset %d of %s", ErrCorruptRecord, offset, LogFileName)
+			}
+		}
+
+		if err != nil {
+			// Drop the torn tail of the log.
+			if err := file.Truncate(offset); err != nil {
+				file.Close()
+				return err
+			}
+
+			break
+		}
+
+		offset += n
+
+		// Records already in the snapshot are left by a crash during
+		// compaction.
+		if rec.seq > s.seq {
+			s.apply(rec)
+			s.ops += len(rec.ops)
+		}
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = offset
+	return nil
+}
+
+// apply sets the operations of the record into the items, returning the
+// changes made.
+func (s *LogStore) apply(rec logRecord) []StoreChange {
+	changes := make([]StoreChange, 0, len(rec.ops))
+
+	for _, op := range rec.ops {
+		if op.removed {
+			delete(s.items, op.key)
+		} else {
+			s.items[op.key] = op.value
+		}
+
+		changes = append(changes, StoreChange{Seq: rec.seq, Key: op.key, Value: op.value, Removed: op.removed})
+	}
+
+	s.seq = rec.seq
+	return changes
+}
+
+// Write appends the operations of the batch to the log as a single record and
+// applies them, so either all or none of them are kept after a crash.
+func (s *LogStore) Write(b *Batch) error {
+	if b.Len() == 0 {
+		return nil
+	}
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	_, err := s.write(b.ops)
+	return err
+}
+
+// write appends and applies the operations and notifies the watchers of the
+// changes, the lock must be held so watchers receive them in the order they
+// were written. The changes are returned if the operations were applied, even
+// if a following compaction failed.
+func (s *LogStore) write(ops []logOp) ([]StoreChange, error) {
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+
+	if s.broken != nil {
+		return nil, s.broken
+	}
+
+	rec := logRecord{seq: s.seq + 1, ops: ops}
+	data := encodeRecord(rec)
+
+	_, err := s.file.Write(data)
+	if err == nil && s.config.SyncWrites {
+		err = s.file.Sync()
+	}
+
+	if err != nil {
+		// Remove the partial record so later records are not dropped with it
+		// when recovering.
+		if terr := s.truncate(s.size); terr != nil {
+			s.broken = fmt.Errorf("ds: log store failed to remove partial record: %s", terr)
+		}
+
+		return nil, err
+	}
+
+	s.size += int64(len(data))
+	s.ops += len(ops)
+
+	changes := s.apply(rec)
+	s.notify(changes)
+
+	if s.config.CompactAfter > 0 && s.ops >= s.config.CompactAfter {
+		if err := s.compact(); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// truncate cuts the log at the giving offset.
+func (s *LogStore) truncate(offset int64) error {
+	if err := s.file.Truncate(offset); err != nil {
+		return err
+	}
+
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// Compact writes the items into a new snapshot and empties the log.
+func (s *LogStore) Compact() error {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	return s.compact()
+}
+
+// compact writes the snapshot and empties the log, the lock must be held.
+func (s *LogStore) compact() error {
+	rec := logRecord{seq: s.seq, ops: make([]logOp, 0, len(s.items))}
+	for k, v := range s.items {
+		rec.ops = append(rec.ops, logOp{key: k, value: v})
+	}
+
+	path := filepath.Join(s.config.Dir, SnapshotFileName)
+
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(encodeRecord(rec)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The rename replaces the snapshot atomically, where the records of the
+	// log are skipped by their sequence if a crash happens before the log
+	// is emptied.
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	syncDir(s.config.Dir)
+
+	if err := s.truncate(0); err != nil {
+		s.broken = fmt.Errorf("ds: log store failed to empty log: %s", err)
+		return s.broken
+	}
+
+	s.size, s.ops = 0, 0
+	return nil
+}
+
+// syncDir syncs the directory so a rename within it is durable.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// compactor compacts the log at the CompactInterval until the store closes.
+func (s *LogStore) compactor() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.CompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.ml.Lock()
+			if !s.closed && s.ops > 0 {
+				if err := s.compact(); err != nil {
+					s.err = err
+				}
+			}
+			s.ml.Unlock()
+		}
+	}
+}
+
+// Err returns the last error of the methods of the Stores interface.
+func (s *LogStore) Err() error {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+	return s.err
+}
+
+// Close stops all watchers and closes the log, the store can not be written
+// afterwards.
+func (s *LogStore) Close() error {
+	s.ml.Lock()
+	if s.closed {
+		s.ml.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	close(s.done)
+	s.ml.Unlock()
+
+	s.wg.Wait()
+
+	s.wl.Lock()
+	for w := range s.watchers {
+		w.stop()
+	}
+	s.watchers = nil
+	s.wl.Unlock()
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+
+	return s.file.Close()
+}
+
+//==============================================================================
+
+// Watch returns a channel receiving the changes of keys with the giving
+// prefix in the order they were written, along with a function which stops
+// the watch and closes the channel. Changes are queued for slow receivers, so
+// writes never wait on them.
+func (s *LogStore) Watch(prefix string) (<-chan StoreChange, func()) {
+	w := &storeWatcher{
+		prefix: prefix,
+		ch:     make(chan StoreChange),
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	go w.run()
+
+	s.wl.Lock()
+	if s.watchers == nil {
+		s.wl.Unlock()
+		w.stop()
+		return w.ch, func() {}
+	}
+
+	s.watchers[w] = true
+	s.wl.Unlock()
+
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			s.wl.Lock()
+			if s.watchers != nil {
+				delete(s.watchers, w)
+			}
+			s.wl.Unlock()
+
+			w.stop()
+		})
+	}
+}
+
+// notify queues the changes for the matching watchers, it never blocks.
+func (s *LogStore) notify(changes []StoreChange) {
+	s.wl.Lock()
+	defer s.wl.Unlock()
+
+	for w := range s.watchers {
+		w.push(changes)
+	}
+}
+
+// storeWatcher defines a single Watch of a LogStore.
+type storeWatcher struct {
+	prefix string
+	ch     chan StoreChange
+	signal chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	ql    sync.Mutex
+	queue []StoreChange
+}
+
+// push queues the changes matching the prefix of the watcher.
+func (w *storeWatcher) push(changes []StoreChange) {
+	w.ql.Lock()
+	for _, change := range changes {
+		if strings.HasPrefix(change.Key, w.prefix) {
+			w.queue = append(w.queue, change)
+		}
+	}
+	w.ql.Unlock()
+
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the watcher.
+func (w *storeWatcher) stop() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+// run delivers the queued changes until the watcher stops.
+func (w *storeWatcher) run() {
+	defer close(w.ch)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.signal:
+		}
+
+		w.ql.Lock()
+		queue := w.queue
+		w.queue = nil
+		w.ql.Unlock()
+
+		for _, change := range queue {
+			select {
+			case w.ch <- change:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+//==============================================================================
+
+// Clone returns an in-memory StringStore with the current items of the store.
+func (s *LogStore) Clone() Stores {
+	return s.snapshot()
+}
+
+// snapshot returns a copy of the current items.
+func (s *LogStore) snapshot() StringStore {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+
+	col := make(StringStore, len(s.items))
+	col.Copy(s.items)
+	return col
+}
+
+// Len returns the total items in the store.
+func (s *LogStore) Len() int {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+	return len(s.items)
+}
+
+// record writes the operations, keeping their error for Err, the lock must
+// be held.
+func (s *LogStore) record(ops []logOp) []StoreChange {
+	changes, err := s.write(ops)
+	if err != nil {
+		s.err = err
+	}
+
+	return changes
+}
+
+// Remove deletes a key:value pair.
+func (s *LogStore) Remove(k string) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if _, ok := s.items[k]; ok {
+		s.record([]logOp{{key: k, removed: true}})
+	}
+}
+
+// Set puts a specific key:value into the store.
+func (s *LogStore) Set(k string, v string) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.record([]logOp{{key: k, value: v}})
+}
+
+// GetOrSet returns the existing value for the key if present, else it sets
+// and returns the giving value. The returned bool is true if the value was
+// found and false if set.
+func (s *LogStore) GetOrSet(k string, v string) (string, bool) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if cur, ok := s.items[k]; ok {
+		return cur, true
+	}
+
+	s.record([]logOp{{key: k, value: v}})
+	return v, false
+}
+
+// CompareAndSwap sets the new value for the key if it exists with a value
+// equal to old, returning true/false if it was swapped.
+func (s *LogStore) CompareAndSwap(k string, old string, new string) bool {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if cur, ok := s.items[k]; !ok || cur != old {
+		return false
+	}
+
+	return s.record([]logOp{{key: k, value: new}}) != nil
+}
+
+// Copy copies the map into the store as a single batch.
+func (s *LogStore) Copy(m map[string]string) {
+	ops := make([]logOp, 0, len(m))
+	for k, v := range m {
+		ops = append(ops, logOp{key: k, value: v})
+	}
+
+	if len(ops) == 0 {
+		return
+	}
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.record(ops)
+}
+
+// Each iterates through a snapshot of all items in the store.
+func (s *LogStore) Each(fx StoreFunc) {
+	s.snapshot().Each(fx)
+}
+
+// Range calls the function for each key:value of a snapshot of the store
+// until it returns false.
+func (s *LogStore) Range(fx func(string, string) bool) {
+	s.snapshot().Range(fx)
+}
+
+// Keys return the keys of the store.
+func (s *LogStore) Keys() []string {
+	return s.snapshot().Keys()
+}
+
+// Get returns the value with the key.
+func (s *LogStore) Get(k string) string {
+	v, _ := s.Lookup(k)
+	return v
+}
+
+// Lookup returns the value with the key and true/false if it exists.
+func (s *LogStore) Lookup(k string) (string, bool) {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+
+	v, ok := s.items[k]
+	return v, ok
+}
+
+// Has returns if a key exists.
+func (s *LogStore) Has(k string) bool {
+	_, ok := s.Lookup(k)
+	return ok
+}
+
+// HasMatch checks if key and value exists and are matching.
+func (s *LogStore) HasMatch(k string, v string) bool {
+	cur, ok := s.Lookup(k)
+	return ok && cur == v
+}
+
+// Clear removes all items from the store as a single batch.
+func (s *LogStore) Clear() {
+	s.ml.Lock()
+	ops := make([]logOp, 0, len(s.items))
+	for k := range s.items {
+		ops = append(ops, logOp{key: k, removed: true})
+	}
+
+	if len(ops) != 0 {
+		s.record(ops)
+	}
+	s.ml.Unlock()
+}
+
+//==============================================================================
+
+// logOp defines a single operation of a log record.
+type logOp struct {
+	key     string
+	value   string
+	removed bool
+}
+
+// logRecord defines a record of the log, holding the operations of a single
+// write and its sequence.
+type logRecord struct {
+	seq uint64
+	ops []logOp
+}
+
+// recordHeaderSize defines the size of the length and checksum preceding the
+// payload of a record.
+const recordHeaderSize = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeRecord returns the record framed by its payload length and checksum.
+func encodeRecord(rec logRecord) []byte {
+	data := make([]byte, recordHeaderSize, recordHeaderSize+64)
+	data = binary.AppendUvarint(data, rec.seq)
+	data = binary.AppendUvarint(data, uint64(len(rec.ops)))
+
+	for _, op := range rec.ops {
+		if op.removed {
+			data = append(data, 1)
+		} else {
+			data = append(data, 0)
+		}
+
+		data = binary.AppendUvarint(data, uint64(len(op.key)))
+		data = append(data, op.key...)
+
+		if !op.removed {
+			data = binary.AppendUvarint(data, uint64(len(op.value)))
+			data = append(data, op.value...)
+		}
+	}
+
+	payload := data[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[4:8], crc32.Checksum(payload, crcTable))
+
+	return data
+}
+
+// readRecord reads a single record from data with the giving remaining size,
+// returning the number of bytes it spans. It returns io.EOF only if no bytes
+// are left and errTornRecord if the record is cut short by the end of the data.
+func readRecord(r io.Reader, remaining int64) (logRecord, int64, error) {
+	var rec logRecord
+
+	header := make([]byte, recordHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF && n == 0 {
+			return rec, 0, io.EOF
+		}
+
+		return rec, 0, errTornRecord
+	}
+
+	// Check the length against the remaining data before allocating, as a
+	// garbage header may ask for a huge payload.
+	length := int64(binary.LittleEndian.Uint32(header[0:4]))
+	if length > remaining-recordHeaderSize {
+		return rec, 0, errTornRecord
+	}
+
+	size := recordHeaderSize + length
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return rec, 0, errTornRecord
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return rec, size, ErrCorruptRecord
+	}
+
+	var err error
+	if rec, err = decodeRecord(payload); err != nil {
+		return rec, size, err
+	}
+
+	return rec, size, nil
+}
+
+// recordAfter returns true if a record with a valid checksum starts anywhere
+// in the data after the giving offset, up to size.
+func recordAfter(r io.ReaderAt, offset, size int64) (bool, error) {
+	data := make([]byte, size-offset)
+	if _, err := r.ReadAt(data, offset); err != nil && err != io.EOF {
+		return false, err
+	}
+
+	for pos := 1; pos+recordHeaderSize <= len(data); pos++ {
+		length := int(binary.LittleEndian.Uint32(data[pos : pos+4]))
+		if length == 0 || length > len(data)-pos-recordHeaderSize {
+			continue
+		}
+
+		payload := data[pos+recordHeaderSize : pos+recordHeaderSize+length]
+		if crc32.Checksum(payload, crcTable) == binary.LittleEndian.Uint32(data[pos+4:pos+8]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// decodeRecord decodes the payload of a record.
+func decodeRecord(data []byte) (logRecord, error) {
+	var rec logRecord
+
+	next := func() (uint64, bool) {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+
+		data = data[n:]
+		return value, true
+	}
+
+	text := func() (string, bool) {
+		size, ok := next()
+		if !ok || size > uint64(len(data)) {
+			return "", false
+		}
+
+		value := string(data[:size])
+		data = data[size:]
+		return value, true
+	}
+
+	seq, ok := next()
+	if !ok {
+		return rec, ErrCorruptRecord
+	}
+
+	count, ok := next()
+	if !ok || count > uint64(len(data)) {
+		return rec, ErrCorruptRecord
+	}
+
+	rec.seq = seq
+	rec.ops = make([]logOp, 0, count)
+
+	for i := uint64(0); i < count; i++ {
+		if len(data) == 0 {
+			return rec, ErrCorruptRecord
+		}
+
+		var op logOp
+		op.removed = data[0] == 1
+		data = data[1:]
+
+		if op.key, ok = text(); !ok {
+			return rec, ErrCorruptRecord
+		}
+
+		if !op.removed {
+			if op.value, ok = text(); !ok {
+				return rec, ErrCorruptRecord
+			}
+		}
+
+		rec.ops = append(rec.ops, op)
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,282 @@
+package ds_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/ds"
+)
+
+var _ ds.Stores = (*ds.LogStore)(nil)
+
+func openStore(t *testing.T, config ds.LogStoreConfig) *ds.LogStore {
+	store, err := ds.OpenLogStore(config)
+	if err != nil {
+		t.Fatalf("Should have opened log store: %s", err)
+	}
+
+	return store
+}
+
+func TestLogStoreRecovery(t *testing.T) {
+	config := ds.LogStoreConfig{Dir: t.TempDir(), CompactAfter: -1}
+
+	store := openStore(t, config)
+	store.Set("a", "1")
+	store.Set("b", "2")
+	store.Remove("a")
+
+	if err := store.Write(ds.NewBatch().Set("c", "3").Set("d", "4").Remove("b")); err != nil {
+		t.Fatalf("Should have written batch: %s", err)
+	}
+	t.Logf("Should have written batch")
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Should have closed log store: %s", err)
+	}
+
+	if store.Set("e", "5"); store.Err() != ds.ErrStoreClosed {
+		t.Fatalf("Should have failed to write into closed store: %v", store.Err())
+	}
+	t.Logf("Should have failed to write into closed store")
+
+	// Simulate a crash during a write by appending a torn record.
+	logPath := filepath.Join(config.Dir, ds.LogFileName)
+
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Should have opened log file: %s", err)
+	}
+	file.Write([]byte{40, 0, 0, 0, 1, 2, 3})
+	file.Close()
+
+	store = openStore(t, config)
+	defer store.Close()
+
+	expected := map[string]string{"c": "3", "d": "4"}
+	if items := map[string]string(store.Clone().(ds.StringStore)); !reflect.DeepEqual(items, expected) {
+		t.Fatalf("Should have recovered complete writes: %+v", items)
+	}
+	t.Logf("Should have recovered complete writes")
+
+	store.Set("e", "5")
+	if err := store.Err(); err != nil {
+		t.Fatalf("Should have written after recovery: %s", err)
+	}
+	store.Close()
+
+	store = openStore(t, config)
+	defer store.Close()
+
+	if store.Get("e") != "5" || store.Len() != 3 {
+		t.Fatalf("Should have kept writes made after dropping torn record")
+	}
+	t.Logf("Should have kept writes made after dropping torn record")
+}
+
+func TestLogStoreCorruption(t *testing.T) {
+	config := ds.LogStoreConfig{Dir: t.TempDir(), CompactAfter: -1}
+	logPath := filepath.Join(config.Dir, ds.LogFileName)
+
+	store := openStore(t, config)
+	store.Set("a", "1")
+	store.Close()
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("Should have written log file: %s", err)
+	}
+	first := info.Size()
+
+	store = openStore(t, config)
+	store.Set("b", "2")
+	store.Close()
+
+	// Simulate a crash leaving a garbage header which asks for a huge payload.
+	file, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Should have opened log file: %s", err)
+	}
+	file.Write([]byte{0xff, 0xff, 0xff, 0xff, 1, 2, 3, 4, 5})
+	file.Close()
+
+	store = openStore(t, config)
+	if store.Get("a") != "1" || store.Get("b") != "2" {
+		t.Fatalf("Should have dropped torn header at end of log: %+v", store.Clone())
+	}
+	t.Logf("Should have dropped torn header at end of log")
+	store.Close()
+
+	// Flip a byte of the first record, leaving the second intact after it.
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Should have read log file: %s", err)
+	}
+	data[first-1] ^= 0xff
+
+	if err := os.WriteFile(logPath, data, 0644); err != nil {
+		t.Fatalf("Should have written log file: %s", err)
+	}
+
+	if _, err := ds.OpenLogStore(config); !errors.Is(err, ds.ErrCorruptRecord) {
+		t.Fatalf("Should have failed to open log with corrupt record: %v", err)
+	}
+	t.Logf("Should have failed to open log with corrupt record")
+
+	if info, err := os.Stat(logPath); err != nil || info.Size() != int64(len(data)) {
+		t.Fatalf("Should have kept log with corrupt record intact: %v", err)
+	}
+	t.Logf("Should have kept log with corrupt record intact")
+}
+
+func TestLogStoreCorruptLength(t *testing.T) {
+	config := ds.LogStoreConfig{Dir: t.TempDir(), CompactAfter: -1}
+	logPath := filepath.Join(config.Dir, ds.LogFileName)
+
+	store := openStore(t, config)
+	store.Set("a", "1")
+	store.Set("b", "2")
+	store.Set("c", "3")
+	store.Close()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Should have read log file: %s", err)
+	}
+
+	// Flip the high byte of the length of the first record, so it runs past
+	// the end of the log.
+	data[3] ^= 0xff
+
+	if err := os.WriteFile(logPath, data, 0644); err != nil {
+		t.Fatalf("Should have written log file: %s", err)
+	}
+
+	if _, err := ds.OpenLogStore(config); !errors.Is(err, ds.ErrCorruptRecord) {
+		t.Fatalf("Should have failed to open log with corrupt record length: %v", err)
+	}
+	t.Logf("Should have failed to open log with corrupt record length")
+
+	if info, err := os.Stat(logPath); err != nil || info.Size() != int64(len(data)) {
+		t.Fatalf("Should have kept log with corrupt record length intact: %v", err)
+	}
+	t.Logf("Should have kept log with corrupt record length intact")
+}
+
+func TestLogStoreCompaction(t *testing.T) {
+	config := ds.LogStoreConfig{Dir: t.TempDir(), CompactAfter: 10}
+
+	store := openStore(t, config)
+	for i := 0; i < 25; i++ {
+		store.Set("count", string(rune('a'+i)))
+	}
+
+	if info, err := os.Stat(filepath.Join(config.Dir, ds.SnapshotFileName)); err != nil || info.Size() == 0 {
+		t.Fatalf("Should have written snapshot: %v", err)
+	}
+	t.Logf("Should have written snapshot")
+
+	store.Set("other", "1")
+	store.Close()
+
+	store = openStore(t, config)
+	defer store.Close()
+
+	if store.Get("count") != "y" || store.Get("other") != "1" {
+		t.Fatalf("Should have recovered from snapshot and log: %+v", store.Clone())
+	}
+	t.Logf("Should have recovered from snapshot and log")
+
+	if !store.CompareAndSwap("other", "1", "2") || store.CompareAndSwap("other", "1", "3") {
+		t.Fatalf("Should have swapped matching value only")
+	}
+	t.Logf("Should have swapped matching value only")
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Should have compacted store: %s", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(config.Dir, ds.LogFileName)); err != nil || info.Size() != 0 {
+		t.Fatalf("Should have emptied log after compaction: %v", err)
+	}
+	t.Logf("Should have emptied log after compaction")
+}
+
+func TestLogStoreWatchOrder(t *testing.T) {
+	store := openStore(t, ds.LogStoreConfig{Dir: t.TempDir(), CompactAfter: -1})
+	defer store.Close()
+
+	changes, stop := store.Watch("")
+	defer stop()
+
+	const writers, writes = 4, 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				store.Set(fmt.Sprintf("%d-%d", i, j), "v")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var last uint64
+	for i := 0; i < writers*writes; i++ {
+		select {
+		case change := <-changes:
+			if change.Seq <= last {
+				t.Fatalf("Should have received changes in written order: %d after %d", change.Seq, last)
+			}
+			last = change.Seq
+		case <-time.After(time.Second):
+			t.Fatalf("Should have received all changes: %d", i)
+		}
+	}
+	t.Logf("Should have received changes in written order")
+}
+
+func TestLogStoreWatch(t *testing.T) {
+	store := openStore(t, ds.LogStoreConfig{Dir: t.TempDir()})
+	defer store.Close()
+
+	changes, stop := store.Watch("user/")
+	defer stop()
+
+	store.Set("user/1", "bob")
+	store.Set("post/1", "hello")
+	store.Write(ds.NewBatch().Set("user/2", "ana").Remove("user/1"))
+
+	var keys []string
+	for len(keys) < 3 {
+		select {
+		case change := <-changes:
+			keys = append(keys, change.Key)
+			if change.Key == "user/1" && len(keys) == 3 && !change.Removed {
+				t.Fatalf("Should have reported removal")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Should have received changes: %+q", keys)
+		}
+	}
+
+	if !reflect.DeepEqual(keys, []string{"user/1", "user/2", "user/1"}) {
+		t.Fatalf("Should have received matching changes in order: %+q", keys)
+	}
+	t.Logf("Should have received matching changes in order")
+
+	stop()
+
+	if _, ok := <-changes; ok {
+		t.Fatalf("Should have closed channel after stop")
+	}
+	t.Logf("Should have closed channel after stop")
+}
@@ -0,0 +1,312 @@
+package ds
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShards defines the shard count used by NewShardedMap when none is
+// provided.
+const DefaultShards = 32
+
+// ShardConfig defines the settings used by NewShardedMap.
+type ShardConfig[K comparable] struct {
+	// Shards sets the number of shards, rounded up to a power of two. It
+	// defaults to DefaultShards.
+	Shards int
+
+	// Hash sets the function used to find the shard of a key, which must give
+	// keys equal with == the same hash. It defaults to a maphash of strings,
+	// integers and, with maphash.Comparable, of other keys. Before Go 1.24
+	// other keys are hashed field by field through reflection, which costs an
+	// allocation per call, so set Hash for struct keys on hot paths.
+	Hash func(K) uint64
+}
+
+// mapShard defines a single shard of a ShardedMap.
+type mapShard[K comparable, V any] struct {
+	rw    sync.RWMutex
+	items map[K]V
+	size  int64
+}
+
+// ShardedMap provides a concurrent Map which splits its keys across shards,
+// each guarded by its own mutex, reducing lock contention under parallel load
+// compared to ConcurrentMap.
+type ShardedMap[K comparable, V any] struct {
+	config ShardConfig[K]
+	mask   uint64
+	shards []*mapShard[K, V]
+}
+
+// NewShardedMap returns a new ShardedMap instance using the giving config.
+func NewShardedMap[K comparable, V any](config ShardConfig[K]) *ShardedMap[K, V] {
+	if config.Shards <= 0 {
+		config.Shards = DefaultShards
+	}
+
+	// Round up to a power of two so shards are found with a mask.
+	config.Shards = 1 << bits.Len(uint(config.Shards-1))
+
+	if config.Hash == nil {
+		config.Hash = defaultHash[K](maphash.MakeSeed())
+	}
+
+	sm := ShardedMap[K, V]{
+		config: config,
+		mask:   uint64(config.Shards - 1),
+		shards: make([]*mapShard[K, V], config.Shards),
+	}
+
+	for index := range sm.shards {
+		sm.shards[index] = &mapShard[K, V]{items: make(map[K]V)}
+	}
+
+	return &sm
+}
+
+// defaultHash returns the default hash function for keys.
+func defaultHash[K comparable](seed maphash.Seed) func(K) uint64 {
+	return func(k K) uint64 {
+		switch key := interface{}(k).(type) {
+		case string:
+			return maphash.String(seed, key)
+		case int:
+			return mix(uint64(key))
+		case int64:
+			return mix(uint64(key))
+		case int32:
+			return mix(uint64(key))
+		case uint:
+			return mix(uint64(key))
+		case uint64:
+			return mix(uint64(key))
+		case uint32:
+			return mix(uint64(key))
+		default:
+			return hashComparable(seed, k)
+		}
+	}
+}
+
+// mix spreads the bits of integer keys, so sequential keys do not fill
+// shards in order.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// shard returns the shard of the giving key.
+func (c *ShardedMap[K, V]) shard(k K) *mapShard[K, V] {
+	return c.shards[c.config.Hash(k)&c.mask]
+}
+
+// Shards returns the number of shards of the map.
+func (c *ShardedMap[K, V]) Shards() int {
+	return len(c.shards)
+}
+
+// Clone makes a new clone of this map with the same config.
+func (c *ShardedMap[K, V]) Clone() Map[K, V] {
+	col := NewShardedMap[K, V](c.config)
+	col.Copy(c.Snapshot())
+	return col
+}
+
+// Len returns the total items in the map, which is approximate when the map
+// is changed concurrently as shards are not locked.
+func (c *ShardedMap[K, V]) Len() int {
+	var total int64
+	for _, shard := range c.shards {
+		total += atomic.LoadInt64(&shard.size)
+	}
+	return int(total)
+}
+
+// Remove deletes a key:value pair.
+func (c *ShardedMap[K, V]) Remove(k K) {
+	shard := c.shard(k)
+	shard.rw.Lock()
+	if _, ok := shard.items[k]; ok {
+		delete(shard.items, k)
+		atomic.AddInt64(&shard.size, -1)
+	}
+	shard.rw.Unlock()
+}
+
+// Set puts a specific key:value into the map.
+func (c *ShardedMap[K, V]) Set(k K, v V) {
+	shard := c.shard(k)
+	shard.rw.Lock()
+	shard.set(k, v)
+	shard.rw.Unlock()
+}
+
+// set puts the key:value into the shard, which must be locked.
+func (s *mapShard[K, V]) set(k K, v V) {
+	if _, ok := s.items[k]; !ok {
+		atomic.AddInt64(&s.size, 1)
+	}
+
+	s.items[k] = v
+}
+
+// Compute atomically sets the value of the key to the result of the function,
+// which receives the current value and true/false if it exists. The key is
+// removed if the function returns false. It returns the new value and
+// true/false if the key exists afterwards. The function runs while the shard
+// of the key is locked, so it must not use the map.
+func (c *ShardedMap[K, V]) Compute(k K, fx func(V, bool) (V, bool)) (V, bool) {
+	shard := c.shard(k)
+	shard.rw.Lock()
+	defer shard.rw.Unlock()
+
+	cur, ok := shard.items[k]
+
+	next, keep := fx(cur, ok)
+	if !keep {
+		if ok {
+			delete(shard.items, k)
+			atomic.AddInt64(&shard.size, -1)
+		}
+
+		var zero V
+		return zero, false
+	}
+
+	shard.set(k, next)
+	return next, true
+}
+
+// GetOrSet returns the existing value for the key if present, else it sets
+// and returns the giving value. The returned bool is true if the value was
+// found and false if set.
+func (c *ShardedMap[K, V]) GetOrSet(k K, v V) (V, bool) {
+	shard := c.shard(k)
+
+	shard.rw.RLock()
+	cur, ok := shard.items[k]
+	shard.rw.RUnlock()
+
+	if ok {
+		return cur, true
+	}
+
+	shard.rw.Lock()
+	defer shard.rw.Unlock()
+
+	if cur, ok := shard.items[k]; ok {
+		return cur, true
+	}
+
+	shard.set(k, v)
+	return v, false
+}
+
+// CompareAndSwap sets the new value for the key if it exists with a value
+// equal to old, returning true/false if it was swapped.
+func (c *ShardedMap[K, V]) CompareAndSwap(k K, old V, new V) bool {
+	shard := c.shard(k)
+	shard.rw.Lock()
+	defer shard.rw.Unlock()
+
+	if cur, ok := shard.items[k]; !ok || !equal(cur, old) {
+		return false
+	}
+
+	shard.items[k] = new
+	return true
+}
+
+// Copy copies the map into the map.
+func (c *ShardedMap[K, V]) Copy(m map[K]V) {
+	for k, v := range m {
+		c.Set(k, v)
+	}
+}
+
+// Snapshot returns a copy of the items of the map at a single point in time,
+// taken while all shards are read locked.
+func (c *ShardedMap[K, V]) Snapshot() HashMap[K, V] {
+	for _, shard := range c.shards {
+		shard.rw.RLock()
+	}
+
+	var total int
+	for _, shard := range c.shards {
+		total += len(shard.items)
+	}
+
+	col := make(HashMap[K, V], total)
+	for _, shard := range c.shards {
+		for k, v := range shard.items {
+			col[k] = v
+		}
+	}
+
+	for _, shard := range c.shards {
+		shard.rw.RUnlock()
+	}
+
+	return col
+}
+
+// Each iterates through all items of a Snapshot of the map.
+func (c *ShardedMap[K, V]) Each(fx EachFunc[K, V]) {
+	c.Snapshot().Each(fx)
+}
+
+// Range calls the function for each key:value of a Snapshot of the map until
+// it returns false. No lock is held while the function runs, so it may modify
+// the map.
+func (c *ShardedMap[K, V]) Range(fx func(K, V) bool) {
+	c.Snapshot().Range(fx)
+}
+
+// Keys return the keys of a Snapshot of the map.
+func (c *ShardedMap[K, V]) Keys() []K {
+	return c.Snapshot().Keys()
+}
+
+// Get returns the value with the key.
+func (c *ShardedMap[K, V]) Get(k K) V {
+	v, _ := c.Lookup(k)
+	return v
+}
+
+// Lookup returns the value with the key and true/false if it exists.
+func (c *ShardedMap[K, V]) Lookup(k K) (V, bool) {
+	shard := c.shard(k)
+	shard.rw.RLock()
+	v, ok := shard.items[k]
+	shard.rw.RUnlock()
+	return v, ok
+}
+
+// Has returns if a key exists.
+func (c *ShardedMap[K, V]) Has(k K) bool {
+	_, ok := c.Lookup(k)
+	return ok
+}
+
+// HasMatch checks if key and value exists and are matching.
+func (c *ShardedMap[K, V]) HasMatch(k K, v V) bool {
+	cur, ok := c.Lookup(k)
+	return ok && equal(cur, v)
+}
+
+// Clear clears the map.
+func (c *ShardedMap[K, V]) Clear() {
+	for _, shard := range c.shards {
+		shard.rw.Lock()
+		shard.items = make(map[K]V)
+		atomic.StoreInt64(&shard.size, 0)
+		shard.rw.Unlock()
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.24
+
+package ds
+
+import "hash/maphash"
+
+// hashComparable returns the maphash of the giving key, which is equal for
+// keys equal with ==.
+func hashComparable[K comparable](seed maphash.Seed, k K) uint64 {
+	return maphash.Comparable(seed, k)
+}
@@ -0,0 +1,79 @@
+//go:build !go1.24
+
+package ds
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// hashComparable returns the maphash of the giving key, which is equal for
+// keys equal with ==. The key is hashed field by field, see hashValue.
+func hashComparable[K comparable](seed maphash.Seed, k K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	hashValue(&h, reflect.ValueOf(&k).Elem())
+	return h.Sum64()
+}
+
+// hashValue writes the giving value into the hash, such that values equal with
+// == write the same bytes. Floats are normalised as 0.0 and -0.0 are equal.
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write(buf[:])
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], floatBits(v.Float()))
+		h.Write(buf[:])
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		binary.LittleEndian.PutUint64(buf[:], floatBits(real(c)))
+		h.Write(buf[:])
+		binary.LittleEndian.PutUint64(buf[:], floatBits(imag(c)))
+		h.Write(buf[:])
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Pointer()))
+		h.Write(buf[:])
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+
+		h.WriteString(v.Elem().Type().String())
+		hashValue(h, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i))
+		}
+	}
+}
+
+// floatBits returns the bits of the float, where -0.0 gives the bits of 0.0.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+
+	return math.Float64bits(f)
+}
@@ -0,0 +1,164 @@
+package ds_test
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/influx6/faux/ds"
+)
+
+var _ ds.Map[string, int] = ds.NewShardedMap[string, int](ds.ShardConfig[string]{})
+
+func TestShardedMap(t *testing.T) {
+	m := ds.NewShardedMap[string, int](ds.ShardConfig[string]{Shards: 5})
+
+	if m.Shards() != 8 {
+		t.Fatalf("Should have rounded shards to a power of two: %d", m.Shards())
+	}
+	t.Logf("Should have rounded shards to a power of two")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Set(strconv.Itoa(i*100+j), j)
+				m.Compute("total", func(v int, _ bool) (int, bool) {
+					return v + 1, true
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if m.Get("total") != 800 {
+		t.Fatalf("Should have computed total atomically: %d", m.Get("total"))
+	}
+	t.Logf("Should have computed total atomically")
+
+	if m.Len() != 801 || len(m.Snapshot()) != 801 {
+		t.Fatalf("Should have counted all items: %d", m.Len())
+	}
+	t.Logf("Should have counted all items")
+
+	if _, ok := m.Compute("total", func(int, bool) (int, bool) { return 0, false }); ok || m.Has("total") {
+		t.Fatalf("Should have removed key when Compute returns false")
+	}
+	t.Logf("Should have removed key when Compute returns false")
+
+	m.Range(func(k string, _ int) bool {
+		m.Remove(k)
+		return true
+	})
+
+	if m.Len() != 0 {
+		t.Fatalf("Should have allowed modifications during Range: %d", m.Len())
+	}
+	t.Logf("Should have allowed modifications during Range")
+
+	hashed := ds.NewShardedMap[int, int](ds.ShardConfig[int]{
+		Shards: 4,
+		Hash:   func(k int) uint64 { return uint64(k) },
+	})
+
+	hashed.Set(1, 1)
+	if v, loaded := hashed.GetOrSet(1, 2); !loaded || v != 1 {
+		t.Fatalf("Should have used custom hash: %d", v)
+	}
+	t.Logf("Should have used custom hash")
+}
+
+const benchKeys = 1024
+
+var keys = func() []string {
+	items := make([]string, benchKeys)
+	for i := range items {
+		items[i] = strconv.Itoa(i)
+	}
+	return items
+}()
+
+// benchmarkMap runs a parallel load of one write per ten reads.
+func benchmarkMap(b *testing.B, get func(string), set func(string, int)) {
+	for _, k := range keys {
+		set(k, 0)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			k := keys[i%benchKeys]
+			if i%10 == 0 {
+				set(k, i)
+			} else {
+				get(k)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkShardedMap(b *testing.B) {
+	m := ds.NewShardedMap[string, interface{}](ds.ShardConfig[string]{})
+	benchmarkMap(b, func(k string) { m.Get(k) }, func(k string, v int) { m.Set(k, v) })
+}
+
+func BenchmarkShardedMapStructKey(b *testing.B) {
+	type key struct {
+		Name string
+		ID   int
+	}
+
+	m := ds.NewShardedMap[key, interface{}](ds.ShardConfig[key]{})
+	benchmarkMap(b, func(k string) { m.Get(key{Name: k}) }, func(k string, v int) { m.Set(key{Name: k}, v) })
+}
+
+func BenchmarkLockMap(b *testing.B) {
+	m := ds.NewLockMap(ds.NewAnyMap())
+	benchmarkMap(b, func(k string) { m.Get(k) }, func(k string, v int) { m.Set(k, v) })
+}
+
+func BenchmarkSyncMap(b *testing.B) {
+	var m sync.Map
+	benchmarkMap(b, func(k string) { m.Load(k) }, func(k string, v int) { m.Store(k, v) })
+}
+
+func TestShardedMapFloatKeys(t *testing.T) {
+	type point struct {
+		X, Y float64
+	}
+
+	zero, negZero := 0.0, math.Copysign(0, -1)
+
+	floats := ds.NewShardedMap[float64, int](ds.ShardConfig[float64]{Shards: 64})
+	points := ds.NewShardedMap[point, int](ds.ShardConfig[point]{Shards: 64})
+	values := ds.NewShardedMap[interface{}, int](ds.ShardConfig[interface{}]{Shards: 64})
+
+	for i := 0; i < 2; i++ {
+		floats.Set(zero, 1)
+		floats.Set(negZero, 2)
+		points.Set(point{X: zero, Y: 1}, 1)
+		points.Set(point{X: negZero, Y: 1}, 2)
+		values.Set(zero, 1)
+		values.Set(negZero, 2)
+	}
+
+	if floats.Len() != 1 || floats.Get(zero) != 2 {
+		t.Fatalf("Should have stored 0.0 and -0.0 as one key: %d", floats.Len())
+	}
+	t.Logf("Should have stored 0.0 and -0.0 as one key")
+
+	if points.Len() != 1 || points.Get(point{Y: 1}) != 2 {
+		t.Fatalf("Should have stored structs with 0.0 and -0.0 as one key: %d", points.Len())
+	}
+	t.Logf("Should have stored structs with 0.0 and -0.0 as one key")
+
+	if values.Len() != 1 || values.Get(zero) != 2 {
+		t.Fatalf("Should have stored interface keys with 0.0 and -0.0 as one key: %d", values.Len())
+	}
+	t.Logf("Should have stored interface keys with 0.0 and -0.0 as one key")
+}
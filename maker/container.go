@@ -0,0 +1,589 @@
+package maker
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influx6/faux/regos"
+)
+
+// Lifetime defines how long a value built by a Container is reused.
+type Lifetime int
+
+// contains the lifetimes of values built by a Container.
+const (
+	// Singleton builds the value once and shares it across the container and
+	// all its scopes.
+	Singleton Lifetime = iota
+
+	// Transient builds a new value for every resolution. Transient values are
+	// not kept by the container, so Start and Stop are never called on them.
+	Transient
+
+	// Scoped builds the value once per scope created with Container.Scope,
+	// where the root container acts as its own scope.
+	Scoped
+)
+
+// String returns the name of the lifetime.
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	default:
+		return fmt.Sprintf("Lifetime(%d)", int(l))
+	}
+}
+
+// Starter defines a value which is started by Container.Start.
+type Starter interface {
+	Start() error
+}
+
+// Stopper defines a value which is stopped by Container.Stop.
+type Stopper interface {
+	Stop() error
+}
+
+// Params defines a marker which can be embedded into a struct used as a
+// constructor parameter, where each field of the struct is resolved on its
+// own. A field with an `inject:"name"` tag is resolved by name, else by type,
+// and the `optional:"true"` tag leaves the field empty when no provider exists.
+type Params struct{}
+
+// CycleError is returned when constructors depend on each other, with the
+// dependency path which leads back to the first constructor.
+type CycleError struct {
+	Path []string
+}
+
+// Error returns the dependency path of the cycle.
+func (c *CycleError) Error() string {
+	return fmt.Sprintf("maker: dependency cycle: %s", strings.Join(c.Path, " -> "))
+}
+
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	paramsType = reflect.TypeOf(Params{})
+)
+
+//==============================================================================
+
+// provider defines a constructor registered with a Container.
+type provider struct {
+	name     string
+	fn       reflect.Value
+	out      reflect.Type
+	errs     bool
+	lifetime Lifetime
+	params   []reflect.Type
+}
+
+// label returns the name of the provider used in errors.
+func (p *provider) label() string {
+	if p.name != "" {
+		return fmt.Sprintf("%s(%q)", p.out, p.name)
+	}
+
+	return p.out.String()
+}
+
+// Container provides a dependency injection container which builds values
+// from constructors with arbitrary parameters, where each parameter is
+// resolved from the constructors registered for its type. Constructors are
+// called while the container is locked, so they must not use the container.
+// Builders registered as a Meta of this package or of regos can be provided
+// with ProvideMeta and ProvideRegos.
+type Container struct {
+	root *Container
+
+	// shared by the root and all scopes, guarded by the root bl lock.
+	bl        *sync.Mutex
+	providers map[reflect.Type]*provider
+	named     map[string]*provider
+
+	// owned by each container.
+	instances map[*provider]reflect.Value
+	order     []reflect.Value
+
+	ll      sync.Mutex
+	started []reflect.Value
+}
+
+// NewContainer returns a new instance of a Container.
+func NewContainer() *Container {
+	c := &Container{
+		bl:        new(sync.Mutex),
+		providers: make(map[reflect.Type]*provider),
+		named:     make(map[string]*provider),
+		instances: make(map[*provider]reflect.Value),
+	}
+
+	c.root = c
+	return c
+}
+
+// Scope returns a new scope of the container which shares its constructors
+// and singletons but builds its own Scoped values.
+func (c *Container) Scope() *Container {
+	return &Container{
+		root:      c.root,
+		bl:        c.bl,
+		providers: c.providers,
+		named:     c.named,
+		instances: make(map[*provider]reflect.Value),
+	}
+}
+
+// Provide registers the giving constructor for the type it returns. The
+// constructor must be a function returning a value or a value and an error,
+// where its parameters are resolved by type when the value is built.
+func (c *Container) Provide(ctor interface{}, lifetime Lifetime) error {
+	return c.ProvideNamed("", ctor, lifetime)
+}
+
+// ProvideNamed registers the giving constructor under the provided name,
+// where the value can only be resolved by name. An empty name registers the
+// constructor for its type like Provide.
+func (c *Container) ProvideNamed(name string, ctor interface{}, lifetime Lifetime) error {
+	fn := reflect.ValueOf(ctor)
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("maker: constructor must be a function, not %T", ctor)
+	}
+
+	ft := fn.Type()
+
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) != errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		return fmt.Errorf("maker: constructor %s must return a value or a value and an error", ft)
+	}
+
+	p := &provider{
+		name:     name,
+		fn:       fn,
+		out:      ft.Out(0),
+		errs:     ft.NumOut() == 2,
+		lifetime: lifetime,
+	}
+
+	for i := 0; i < ft.NumIn(); i++ {
+		p.params = append(p.params, ft.In(i))
+	}
+
+	c.bl.Lock()
+	defer c.bl.Unlock()
+
+	if name != "" {
+		if _, ok := c.named[name]; ok {
+			return fmt.Errorf("maker: %q already provided", name)
+		}
+
+		c.named[name] = p
+		return nil
+	}
+
+	if existing, ok := c.providers[p.out]; ok {
+		return fmt.Errorf("maker: %s already provided by %s", p.out, existing.fn.Type())
+	}
+
+	c.providers[p.out] = p
+	return nil
+}
+
+// ProvideMeta registers the Inject function of the giving Meta as the
+// constructor of the type it returns, which is built with the provided config
+// using Meta.Build. An empty name registers it for its type like Provide.
+func (c *Container) ProvideMeta(name string, meta *Meta, config interface{}, lifetime Lifetime) error {
+	return c.provideBuild(name, meta.Inject, func() (interface{}, error) {
+		return meta.Build(config)
+	}, lifetime)
+}
+
+// ProvideRegos registers the Inject function of the giving regos.Meta as the
+// constructor of the type it returns, which is built with the provided config
+// using regos.Meta.BuildE. An empty name registers it for its type like
+// Provide.
+func (c *Container) ProvideRegos(name string, meta regos.Meta, config interface{}, lifetime Lifetime) error {
+	return c.provideBuild(name, meta.Inject, func() (interface{}, error) {
+		return meta.BuildE(config)
+	}, lifetime)
+}
+
+// provideBuild registers a constructor of the type returned by the inject
+// function, which calls the giving build function.
+func (c *Container) provideBuild(name string, inject interface{}, build func() (interface{}, error), lifetime Lifetime) error {
+	ft := reflect.TypeOf(inject)
+	if ft == nil || ft.Kind() != reflect.Func || ft.NumOut() == 0 || ft.Out(0) == errorType {
+		return fmt.Errorf("maker: Meta requires an Inject function returning a value, not %T", inject)
+	}
+
+	out := ft.Out(0)
+	ctor := reflect.MakeFunc(reflect.FuncOf(nil, []reflect.Type{out, errorType}, false), func([]reflect.Value) []reflect.Value {
+		val := reflect.New(out).Elem()
+		failed := reflect.New(errorType).Elem()
+
+		built, err := build()
+		if err != nil {
+			failed.Set(reflect.ValueOf(err))
+		} else if built != nil {
+			val.Set(reflect.ValueOf(built))
+		}
+
+		return []reflect.Value{val, failed}
+	})
+
+	return c.ProvideNamed(name, ctor.Interface(), lifetime)
+}
+
+// Get resolves the value for the type pointed to by target and sets it.
+func (c *Container) Get(target interface{}) error {
+	return c.GetNamed("", target)
+}
+
+// GetNamed resolves the value registered under the giving name and sets it
+// into the value pointed to by target. An empty name resolves by type.
+func (c *Container) GetNamed(name string, target interface{}) error {
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Ptr || tv.IsNil() {
+		return fmt.Errorf("maker: target must be a non-nil pointer, not %T", target)
+	}
+
+	c.bl.Lock()
+	defer c.bl.Unlock()
+
+	val, err := c.resolve(name, tv.Elem().Type(), nil)
+	if err != nil {
+		return err
+	}
+
+	tv.Elem().Set(val)
+	return nil
+}
+
+// Invoke calls the giving function with its parameters resolved from the
+// container, returning the error of the function if its last result is one.
+func (c *Container) Invoke(fx interface{}) error {
+	fn := reflect.ValueOf(fx)
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("maker: Invoke requires a function, not %T", fx)
+	}
+
+	ft := fn.Type()
+
+	args, err := c.resolveArgs(ft)
+	if err != nil {
+		return err
+	}
+
+	res := fn.Call(args)
+	if len(res) > 0 && ft.Out(len(res)-1) == errorType && !res[len(res)-1].IsNil() {
+		return res[len(res)-1].Interface().(error)
+	}
+
+	return nil
+}
+
+// resolveArgs returns the values of the parameters of the giving function
+// type.
+func (c *Container) resolveArgs(ft reflect.Type) ([]reflect.Value, error) {
+	c.bl.Lock()
+	defer c.bl.Unlock()
+
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		arg, err := c.resolveParam(ft.In(i), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = arg
+	}
+
+	return args, nil
+}
+
+// Start builds all singletons of the root container and calls Start on all
+// values built by the container which implement Starter, in dependency order
+// so values are started after their dependencies. If a value fails to start,
+// the values already started are stopped and the error is returned, wrapping
+// the error of stopping them if any.
+func (c *Container) Start() error {
+	c.ll.Lock()
+	defer c.ll.Unlock()
+
+	order, err := c.unstarted()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order {
+		if starter, ok := item.Interface().(Starter); ok {
+			if err := starter.Start(); err != nil {
+				stopErr := c.stop(c.started)
+				c.started = nil
+
+				if stopErr != nil {
+					return fmt.Errorf("maker: failed to start %s: %s, rollback: %w", item.Type(), err, stopErr)
+				}
+
+				return fmt.Errorf("maker: failed to start %s: %s", item.Type(), err)
+			}
+		}
+
+		c.started = append(c.started, item)
+	}
+
+	return nil
+}
+
+// unstarted builds all singletons of the root container and returns the
+// values which are not started yet.
+func (c *Container) unstarted() ([]reflect.Value, error) {
+	c.bl.Lock()
+	defer c.bl.Unlock()
+
+	if c == c.root {
+		for _, p := range c.sortedProviders() {
+			if p.lifetime != Singleton {
+				continue
+			}
+
+			if _, err := c.build(p, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Values are started in the order they were built, which is a prefix of
+	// the build order as values are only appended.
+	order := make([]reflect.Value, len(c.order)-len(c.started))
+	copy(order, c.order[len(c.started):])
+
+	return order, nil
+}
+
+// Stop calls Stop on all values built by the container which implement
+// Stopper, in reverse dependency order, including values built after Start.
+// For a scope, all Scoped values built by it are stopped. It returns the errors
+// of all failed values together.
+func (c *Container) Stop() error {
+	c.ll.Lock()
+	defer c.ll.Unlock()
+
+	c.bl.Lock()
+	items := make([]reflect.Value, len(c.order))
+	copy(items, c.order)
+	c.bl.Unlock()
+
+	c.started = nil
+	return c.stop(items)
+}
+
+// stop calls Stop on the giving values in reverse order.
+func (c *Container) stop(items []reflect.Value) error {
+	var failed []string
+
+	for i := len(items) - 1; i >= 0; i-- {
+		if stopper, ok := items[i].Interface().(Stopper); ok {
+			if err := stopper.Stop(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", items[i].Type(), err))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("maker: failed to stop %s", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+//==============================================================================
+
+// resolve returns the value for the name or type, expects the lock to be held.
+func (c *Container) resolve(name string, tl reflect.Type, path []*provider) (reflect.Value, error) {
+	var p *provider
+	var ok bool
+
+	if name != "" {
+		if p, ok = c.named[name]; ok && !p.out.AssignableTo(tl) {
+			return reflect.Value{}, fmt.Errorf("maker: %q provides %s, not %s%s", name, p.out, tl, requiredBy(path))
+		}
+	} else {
+		p, ok = c.providers[tl]
+	}
+
+	if !ok {
+		what := tl.String()
+		if name != "" {
+			what = fmt.Sprintf("%q", name)
+		}
+
+		return reflect.Value{}, fmt.Errorf("maker: no provider for %s%s", what, requiredBy(path))
+	}
+
+	return c.build(p, path)
+}
+
+// resolveParam returns the value of a constructor parameter, which may be a
+// struct embedding Params.
+func (c *Container) resolveParam(tl reflect.Type, path []*provider) (reflect.Value, error) {
+	if !isParams(tl) {
+		return c.resolve("", tl, path)
+	}
+
+	val := reflect.New(tl).Elem()
+
+	for i := 0; i < tl.NumField(); i++ {
+		field := tl.Field(i)
+		if field.Type == paramsType || field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get("inject")
+		if field.Tag.Get("optional") == "true" && !c.has(name, field.Type) {
+			continue
+		}
+
+		item, err := c.resolve(name, field.Type, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		val.Field(i).Set(item)
+	}
+
+	return val, nil
+}
+
+// build returns the value of the provider, reusing values based on its
+// lifetime, expects the lock to be held.
+func (c *Container) build(p *provider, path []*provider) (reflect.Value, error) {
+	owner := c
+	switch p.lifetime {
+	case Singleton:
+		owner = c.root
+	case Transient:
+		owner = nil
+	}
+
+	if owner != nil {
+		if val, ok := owner.instances[p]; ok {
+			return val, nil
+		}
+	}
+
+	for index, item := range path {
+		if item == p {
+			labels := make([]string, 0, len(path)-index+1)
+			for _, step := range path[index:] {
+				labels = append(labels, step.label())
+			}
+
+			return reflect.Value{}, &CycleError{Path: append(labels, p.label())}
+		}
+	}
+
+	path = append(path[:len(path):len(path)], p)
+
+	// Singletons resolve their dependencies from the root container.
+	scope := c
+	if p.lifetime == Singleton {
+		scope = c.root
+	}
+
+	args := make([]reflect.Value, len(p.params))
+	for i, param := range p.params {
+		arg, err := scope.resolveParam(param, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		args[i] = arg
+	}
+
+	res := p.fn.Call(args)
+	if p.errs && !res[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("maker: failed to build %s%s: %s", p.label(), requiredBy(path[:len(path)-1]), res[1].Interface())
+	}
+
+	val := res[0]
+
+	if owner != nil {
+		owner.instances[p] = val
+		owner.order = append(owner.order, val)
+	}
+
+	return val, nil
+}
+
+// sortedProviders returns all providers in a stable order.
+func (c *Container) sortedProviders() []*provider {
+	var items []*provider
+	labels := make(map[*provider]string)
+
+	for _, p := range c.providers {
+		items = append(items, p)
+		labels[p] = p.label()
+	}
+
+	for _, p := range c.named {
+		items = append(items, p)
+		labels[p] = p.label()
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return labels[items[i]] < labels[items[j]]
+	})
+
+	return items
+}
+
+// has returns true/false if a provider exists for the name or type.
+func (c *Container) has(name string, tl reflect.Type) bool {
+	if name != "" {
+		_, ok := c.named[name]
+		return ok
+	}
+
+	_, ok := c.providers[tl]
+	return ok
+}
+
+// requiredBy returns the dependency path leading to a resolution.
+func requiredBy(path []*provider) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0, len(path))
+	for _, p := range path {
+		labels = append(labels, p.label())
+	}
+
+	return fmt.Sprintf(" (required by %s)", strings.Join(labels, " -> "))
+}
+
+// isParams returns true/false if the type is a struct embedding Params.
+func isParams(tl reflect.Type) bool {
+	if tl.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < tl.NumField(); i++ {
+		if field := tl.Field(i); field.Anonymous && field.Type == paramsType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,353 @@
+package maker_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/maker"
+	"github.com/influx6/faux/regos"
+)
+
+type config struct {
+	DSN string
+}
+
+type database struct {
+	config *config
+	events *[]string
+}
+
+func (d *database) Start() error {
+	*d.events = append(*d.events, "start database")
+	return nil
+}
+
+func (d *database) Stop() error {
+	*d.events = append(*d.events, "stop database")
+	return nil
+}
+
+type server struct {
+	db     *database
+	name   string
+	events *[]string
+}
+
+func (s *server) Start() error {
+	*s.events = append(*s.events, "start server")
+	return nil
+}
+
+func (s *server) Stop() error {
+	*s.events = append(*s.events, "stop server")
+	return nil
+}
+
+type request struct {
+	ID int
+}
+
+type serverParams struct {
+	maker.Params
+
+	DB      *database
+	Name    string   `inject:"server.name"`
+	Missing *request `optional:"true"`
+}
+
+// TestContainer validates resolving constructors and lifecycle hooks.
+func TestContainer(t *testing.T) {
+	var events []string
+
+	c := maker.NewContainer()
+
+	mustProvide(t, c.Provide(func(params serverParams) (*server, error) {
+		if params.Missing != nil {
+			return nil, errors.New("optional field should be empty")
+		}
+
+		return &server{db: params.DB, name: params.Name, events: &events}, nil
+	}, maker.Singleton), nil)
+
+	mustProvide(t, c.Provide(func(cfg *config) *database {
+		return &database{config: cfg, events: &events}
+	}, maker.Singleton), nil)
+
+	mustProvide(t, c.Provide(func() *config { return &config{DSN: "mem://"} }, maker.Singleton), nil)
+	mustProvide(t, c.ProvideNamed("server.name", func() string { return "api" }, maker.Transient), nil)
+	mustProvide(t, c.Provide(func() *config { return nil }, maker.Singleton), errors.New("already provided"))
+	mustProvide(t, c.Provide(func() {}, maker.Singleton), errors.New("must return"))
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("Should have started container: %s", err)
+	}
+	t.Logf("Should have started container")
+
+	var srv *server
+	if err := c.Get(&srv); err != nil {
+		t.Fatalf("Should have resolved server: %s", err)
+	}
+
+	if srv.name != "api" || srv.db == nil || srv.db.config.DSN != "mem://" {
+		t.Fatalf("Should have resolved server dependencies: %+v", srv)
+	}
+	t.Logf("Should have resolved server dependencies")
+
+	var again *server
+	c.Get(&again)
+
+	if again != srv {
+		t.Fatalf("Should have reused singleton")
+	}
+	t.Logf("Should have reused singleton")
+
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Should have stopped container: %s", err)
+	}
+
+	expected := "start database,start server,stop server,stop database"
+	if strings.Join(events, ",") != expected {
+		t.Fatalf("Should have run hooks in dependency order: %+q", events)
+	}
+	t.Logf("Should have run hooks in dependency order")
+}
+
+type failing struct {
+	name   string
+	events *[]string
+}
+
+func (f *failing) Start() error {
+	*f.events = append(*f.events, "start "+f.name)
+	return errors.New("start failed")
+}
+
+// TestContainerLifecycle validates stopping values built after Start and
+// rolling back a failed Start.
+func TestContainerLifecycle(t *testing.T) {
+	var events []string
+
+	c := maker.NewContainer()
+
+	mustProvide(t, c.Provide(func() *database { return &database{events: &events} }, maker.Singleton), nil)
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("Should have started container: %s", err)
+	}
+
+	mustProvide(t, c.Provide(func(db *database) *server {
+		return &server{db: db, events: &events}
+	}, maker.Singleton), nil)
+
+	var srv *server
+	if err := c.Get(&srv); err != nil {
+		t.Fatalf("Should have resolved server built after start: %s", err)
+	}
+
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Should have stopped container: %s", err)
+	}
+
+	expected := "start database,stop server,stop database"
+	if strings.Join(events, ",") != expected {
+		t.Fatalf("Should have stopped values built after start: %+q", events)
+	}
+	t.Logf("Should have stopped values built after start")
+
+	events = nil
+
+	c = maker.NewContainer()
+
+	mustProvide(t, c.Provide(func() stopFunc {
+		return func() error { return errors.New("stop failed") }
+	}, maker.Singleton), nil)
+	mustProvide(t, c.Provide(func(stopFunc) *database { return &database{events: &events} }, maker.Singleton), nil)
+	mustProvide(t, c.Provide(func(*database) *failing { return &failing{name: "failing", events: &events} }, maker.Singleton), nil)
+
+	err := c.Start()
+	if err == nil || !strings.Contains(err.Error(), "start failed") || !strings.Contains(err.Error(), "stop failed") {
+		t.Fatalf("Should have returned start error wrapping rollback error: %v", err)
+	}
+	t.Logf("Should have returned start error wrapping rollback error")
+
+	expected = "start database,start failing,stop database"
+	if strings.Join(events, ",") != expected {
+		t.Fatalf("Should have stopped started values on failed start: %+q", events)
+	}
+	t.Logf("Should have stopped started values on failed start")
+}
+
+// TestContainerPanics validates the container stays usable after a
+// constructor panics.
+func TestContainerPanics(t *testing.T) {
+	c := maker.NewContainer()
+
+	mustProvide(t, c.Provide(func() *config { panic("bad constructor") }, maker.Singleton), nil)
+
+	for name, fn := range map[string]func() error{
+		"Invoke": func() error { return c.Invoke(func(*config) {}) },
+		"Start":  c.Start,
+	} {
+		func() {
+			defer func() {
+				if ex := recover(); ex == nil {
+					t.Errorf("Should have propagated constructor panic from %s", name)
+				}
+			}()
+
+			fn()
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Provide(func() *request { return &request{} }, maker.Transient)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Should have provided after constructor panic: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Should have unlocked container after constructor panic")
+	}
+	t.Logf("Should have unlocked container after constructor panic")
+}
+
+// TestContainerMeta validates providing values built by a Meta.
+func TestContainerMeta(t *testing.T) {
+	c := maker.NewContainer()
+
+	err := c.ProvideMeta("", &maker.Meta{
+		Name:   "config",
+		Inject: func(dsn string) *config { return &config{DSN: dsn} },
+	}, "mem://", maker.Singleton)
+	mustProvide(t, err, nil)
+
+	err = c.ProvideRegos("server.name", regos.Meta{
+		Name:   "name",
+		Desc:   "builds the server name",
+		Inject: func(cfg config) (string, error) { return "api:" + cfg.DSN, nil },
+	}, config{DSN: "db"}, maker.Transient)
+	mustProvide(t, err, nil)
+
+	var cfg *config
+	if err := c.Get(&cfg); err != nil || cfg.DSN != "mem://" {
+		t.Fatalf("Should have resolved value built by maker.Meta: %+v %v", cfg, err)
+	}
+	t.Logf("Should have resolved value built by maker.Meta")
+
+	var name string
+	if err := c.GetNamed("server.name", &name); err != nil || name != "api:db" {
+		t.Fatalf("Should have resolved value built by regos.Meta: %q %v", name, err)
+	}
+	t.Logf("Should have resolved value built by regos.Meta")
+
+	failed := c.ProvideRegos("broken", regos.Meta{
+		Name:   "broken",
+		Inject: func(cfg config) (*request, error) { return nil, errors.New("bad config") },
+	}, config{}, maker.Transient)
+	mustProvide(t, failed, nil)
+
+	var req *request
+	if err := c.GetNamed("broken", &req); err == nil || !strings.Contains(err.Error(), "bad config") {
+		t.Fatalf("Should have returned build error of regos.Meta: %v", err)
+	}
+	t.Logf("Should have returned build error of regos.Meta")
+}
+
+type stopFunc func() error
+
+func (fn stopFunc) Stop() error {
+	return fn()
+}
+
+// TestContainerScopes validates scoped and transient lifetimes.
+func TestContainerScopes(t *testing.T) {
+	var count int
+
+	c := maker.NewContainer()
+
+	mustProvide(t, c.Provide(func() *request {
+		count++
+		return &request{ID: count}
+	}, maker.Scoped), nil)
+
+	mustProvide(t, c.ProvideNamed("id", func(r *request) int {
+		return r.ID
+	}, maker.Transient), nil)
+
+	first, second := c.Scope(), c.Scope()
+
+	var a, b, c1 *request
+	first.Get(&a)
+	first.Get(&b)
+	second.Get(&c1)
+
+	if a != b || a == c1 {
+		t.Fatalf("Should have shared values within a scope only")
+	}
+	t.Logf("Should have shared values within a scope only")
+
+	var id int
+	if err := second.GetNamed("id", &id); err != nil || id != c1.ID {
+		t.Fatalf("Should have resolved named transient from scope: %d %v", id, err)
+	}
+	t.Logf("Should have resolved named transient from scope")
+
+	if err := c.Invoke(func(r *request) {
+		if r == a || r == c1 {
+			t.Errorf("Should have built new value for root scope")
+		}
+	}); err != nil {
+		t.Fatalf("Should have invoked function: %s", err)
+	}
+	t.Logf("Should have invoked function")
+}
+
+// TestContainerCycles validates the detection of dependency cycles.
+func TestContainerCycles(t *testing.T) {
+	c := maker.NewContainer()
+
+	mustProvide(t, c.Provide(func(db *database) *server { return nil }, maker.Singleton), nil)
+	mustProvide(t, c.Provide(func(cfg *config) *database { return nil }, maker.Singleton), nil)
+	mustProvide(t, c.Provide(func(srv *server) *config { return nil }, maker.Transient), nil)
+
+	var srv *server
+	err := c.Get(&srv)
+
+	cycle, ok := err.(*maker.CycleError)
+	if !ok {
+		t.Fatalf("Should have failed with cycle error: %#v", err)
+	}
+
+	expected := "maker: dependency cycle: *maker_test.server -> *maker_test.database -> *maker_test.config -> *maker_test.server"
+	if cycle.Error() != expected {
+		t.Fatalf("Should have reported dependency path: %s", cycle)
+	}
+	t.Logf("Should have reported dependency path: %s", cycle)
+
+	c = maker.NewContainer()
+	mustProvide(t, c.Provide(func(db *database) *server { return nil }, maker.Singleton), nil)
+
+	err = c.Get(&srv)
+	if err == nil || err.Error() != "maker: no provider for *maker_test.database (required by *maker_test.server)" {
+		t.Fatalf("Should have reported missing dependency: %v", err)
+	}
+	t.Logf("Should have reported missing dependency: %s", err)
+}
+
+func mustProvide(t *testing.T, err error, expected error) {
+	t.Helper()
+
+	if expected == nil && err != nil {
+		t.Fatalf("Should have provided constructor: %s", err)
+	}
+
+	if expected != nil && (err == nil || !strings.Contains(err.Error(), expected.Error())) {
+		t.Fatalf("Should have failed with %q: %v", expected, err)
+	}
+}
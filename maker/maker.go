@@ -95,6 +95,44 @@ func (v *Maker) Create(name string, param interface{}) (interface{}, error) {
 	return vss, nil
 }
 
+// CreateRaw builds the item with the giving name using the config decoded from
+// the raw JSON or YAML, see Meta.Decode.
+func (v *Maker) CreateRaw(name string, raw []byte) (interface{}, error) {
+	v.rw.RLock()
+	mv, ok := v.makers[name]
+	v.rw.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+
+	config, err := mv.Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, err := mv.Build(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.transform.Transform(vs), nil
+}
+
+// Schema returns the JSON Schema of the config of the item with the giving
+// name, see Meta.Schema.
+func (v *Maker) Schema(name string) (*reflection.Schema, error) {
+	v.rw.RLock()
+	mv, ok := v.makers[name]
+	v.rw.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+
+	return mv.Schema()
+}
+
 //==============================================================================
 
 // Meta provides a registry structure for registering building structures.
@@ -151,4 +189,41 @@ func (p *Meta) Build(config interface{}) (interface{}, error) {
 	return res.Interface(), nil
 }
 
+// Schema returns the JSON Schema of the config expected by the Inject function,
+// see reflection.SchemaOf. It returns an error if it takes no config.
+func (p *Meta) Schema() (*reflection.Schema, error) {
+	args, err := reflection.GetFuncArgumentsType(p.Inject)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Build[%s] takes no config", p.Name)
+	}
+
+	return reflection.SchemaOf(args[0])
+}
+
+// Decode returns the config expected by the Inject function decoded from the
+// raw JSON or YAML, after validating it against the Schema of the config, see
+// reflection.DecodeConfig. YAML requires importing reflection/yamlconfig. It
+// returns nil if the Inject function takes no config.
+func (p *Meta) Decode(raw []byte) (interface{}, error) {
+	args, err := reflection.GetFuncArgumentsType(p.Inject)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	target := reflect.New(args[0])
+	if err := reflection.DecodeConfig(raw, target.Interface()); err != nil {
+		return nil, fmt.Errorf("Build[%s] has invalid config: %s", p.Name, err)
+	}
+
+	return target.Elem().Interface(), nil
+}
+
 //==============================================================================
@@ -0,0 +1,345 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// contains errors returned by the AsyncMaster.
+var (
+	// ErrBufferFull is returned by AsyncMaster.Emit when the ring buffer has no
+	// space left and the entry was dropped.
+	ErrBufferFull = errors.New("metrics: async buffer is full, entry dropped")
+
+	// ErrClosed is returned when entries are emitted into a closed AsyncMaster.
+	ErrClosed = errors.New("metrics: async master is closed")
+)
+
+// contains default values used by the AsyncMaster when its config is empty.
+const (
+	DefaultBufferSize    = 1 << 10
+	DefaultBatchSize     = 1 << 6
+	DefaultFlushInterval = 500 * time.Millisecond
+)
+
+//==============================================================================
+
+// BatchSentry defines an optional interface which a Sentry can implement to
+// receive a batch of entries in a single call from the AsyncMaster.
+type BatchSentry interface {
+	Sentry
+	EmitBatch([]SentryJSON) error
+}
+
+// SinkError defines the error delivered to AsyncConfig.OnError when a giving
+// sink fails to receive entries.
+type SinkError struct {
+	Sink    interface{}
+	Entries int
+	Err     error
+}
+
+// Error returns the error message for the failed sink.
+func (s SinkError) Error() string {
+	return fmt.Sprintf("metrics: sink %T failed for %d entries: %s", s.Sink, s.Entries, s.Err)
+}
+
+// AsyncConfig defines the configuration used by an AsyncMaster.
+type AsyncConfig struct {
+	// BufferSize sets the max number of entries held by the ring buffer.
+	BufferSize int
+
+	// BatchSize sets the max number of entries delivered to sinks at once.
+	BatchSize int
+
+	// FlushInterval sets the period at which the buffer is flushed even when
+	// no new entry has arrived.
+	FlushInterval time.Duration
+
+	// OnError is called with a SinkError for every failed delivery. Failures of
+	// one sink never stop delivery to other sinks.
+	OnError func(error)
+}
+
+// AsyncMaster defines a metrics which buffers entries in a bounded ring buffer
+// and delivers them to its sinks from a background goroutine.
+type AsyncMaster struct {
+	config   AsyncConfig
+	metrics  []Metrics
+	sentries []Sentry
+
+	dropped int64
+	failed  int64
+
+	ml        sync.Mutex
+	ring      []asyncEntry
+	head      int
+	size      int
+	enqueued  int64
+	delivered int64
+	closed    bool
+	progress  chan struct{}
+
+	signal chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// asyncEntry defines an entry held by the ring buffer of an AsyncMaster along
+// with the time it was emitted.
+type asyncEntry struct {
+	entry Entry
+	time  time.Time
+}
+
+// NewAsync returns a new AsyncMaster which delivers to the provided Metrics
+// and Sentry values, using the same rules as New.
+func NewAsync(config AsyncConfig, metrics ...interface{}) *AsyncMaster {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultBufferSize
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	var am AsyncMaster
+	am.config = config
+	am.ring = make([]asyncEntry, config.BufferSize)
+	am.progress = make(chan struct{})
+	am.signal = make(chan struct{}, 1)
+	am.done = make(chan struct{})
+
+	for _, item := range metrics {
+		switch rItem := item.(type) {
+		case Metrics:
+			am.metrics = append(am.metrics, rItem)
+		case Sentry:
+			am.sentries = append(am.sentries, rItem)
+		}
+	}
+
+	am.wg.Add(1)
+	go am.run()
+
+	return &am
+}
+
+// Dropped returns the total number of entries dropped due to a full buffer.
+func (a *AsyncMaster) Dropped() int64 {
+	return atomic.LoadInt64(&a.dropped)
+}
+
+// Failed returns the total number of failed sink deliveries.
+func (a *AsyncMaster) Failed() int64 {
+	return atomic.LoadInt64(&a.failed)
+}
+
+// Len returns the number of entries currently waiting in the buffer.
+func (a *AsyncMaster) Len() int {
+	a.ml.Lock()
+	defer a.ml.Unlock()
+	return a.size
+}
+
+// Emit adds the giving entry into the ring buffer without blocking. It returns
+// ErrBufferFull if the entry had to be dropped. The time of the call is used as
+// the time of the SentryJSON delivered to sentries.
+func (a *AsyncMaster) Emit(e Entry) error {
+	now := time.Now()
+
+	a.ml.Lock()
+
+	if a.closed {
+		a.ml.Unlock()
+		return ErrClosed
+	}
+
+	if a.size == len(a.ring) {
+		a.ml.Unlock()
+		atomic.AddInt64(&a.dropped, 1)
+		return ErrBufferFull
+	}
+
+	a.ring[(a.head+a.size)%len(a.ring)] = asyncEntry{entry: e, time: now}
+	a.size++
+	a.enqueued++
+
+	full := a.size >= a.config.BatchSize
+	a.ml.Unlock()
+
+	if full {
+		a.notify()
+	}
+
+	return nil
+}
+
+// Flush blocks until all entries emitted before the call have been delivered
+// to the sinks or until the provided context expires.
+func (a *AsyncMaster) Flush(ctx context.Context) error {
+	a.ml.Lock()
+	target := a.enqueued
+	a.ml.Unlock()
+
+	a.notify()
+
+	for {
+		a.ml.Lock()
+		if a.delivered >= target {
+			a.ml.Unlock()
+			return nil
+		}
+
+		progress := a.progress
+		a.ml.Unlock()
+
+		select {
+		case <-progress:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close stops the AsyncMaster from accepting new entries, flushes all pending
+// entries and stops the background goroutine.
+func (a *AsyncMaster) Close(ctx context.Context) error {
+	a.ml.Lock()
+	if a.closed {
+		a.ml.Unlock()
+		return ErrClosed
+	}
+
+	a.closed = true
+	a.ml.Unlock()
+
+	err := a.Flush(ctx)
+
+	close(a.done)
+	a.wg.Wait()
+
+	return err
+}
+
+// notify wakes the background goroutine if it is not already signaled.
+func (a *AsyncMaster) notify() {
+	select {
+	case a.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run drains the buffer whenever signaled or on every flush interval.
+func (a *AsyncMaster) run() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			a.drain()
+			return
+		case <-a.signal:
+			a.drain()
+		case <-ticker.C:
+			a.drain()
+		}
+	}
+}
+
+// drain delivers batches until the buffer is empty.
+func (a *AsyncMaster) drain() {
+	for {
+		batch := a.take()
+		if len(batch) == 0 {
+			return
+		}
+
+		a.deliver(batch)
+
+		a.ml.Lock()
+		a.delivered += int64(len(batch))
+		close(a.progress)
+		a.progress = make(chan struct{})
+		a.ml.Unlock()
+	}
+}
+
+// take removes at most BatchSize entries from the ring buffer.
+func (a *AsyncMaster) take() []asyncEntry {
+	a.ml.Lock()
+	defer a.ml.Unlock()
+
+	total := a.size
+	if total > a.config.BatchSize {
+		total = a.config.BatchSize
+	}
+
+	batch := make([]asyncEntry, total)
+	for i := 0; i < total; i++ {
+		index := (a.head + i) % len(a.ring)
+		batch[i] = a.ring[index]
+		a.ring[index] = asyncEntry{}
+	}
+
+	a.head = (a.head + total) % len(a.ring)
+	a.size -= total
+
+	return batch
+}
+
+// deliver sends the batch to every sink, isolating failures per sink.
+func (a *AsyncMaster) deliver(batch []asyncEntry) {
+	for _, metric := range a.metrics {
+		for _, item := range batch {
+			if err := metric.Emit(item.entry); err != nil {
+				a.fail(metric, 1, err)
+			}
+		}
+	}
+
+	if len(a.sentries) == 0 {
+		return
+	}
+
+	jsons := make([]SentryJSON, len(batch))
+	for index, item := range batch {
+		jsons[index] = ToSentryJSON(item.entry)
+		jsons[index].Time = item.time
+	}
+
+	for _, sentry := range a.sentries {
+		if bs, ok := sentry.(BatchSentry); ok {
+			if err := bs.EmitBatch(jsons); err != nil {
+				a.fail(sentry, len(jsons), err)
+			}
+			continue
+		}
+
+		for _, sjn := range jsons {
+			if err := sentry.Emit(sjn); err != nil {
+				a.fail(sentry, 1, err)
+			}
+		}
+	}
+}
+
+// fail records the failure of a sink and reports it to the error hook.
+func (a *AsyncMaster) fail(sink interface{}, entries int, err error) {
+	atomic.AddInt64(&a.failed, 1)
+
+	if a.config.OnError != nil {
+		a.config.OnError(SinkError{Sink: sink, Entries: entries, Err: err})
+	}
+}
@@ -0,0 +1,182 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+type batchSentry struct {
+	ml      sync.Mutex
+	batches int
+	data    []metrics.SentryJSON
+}
+
+func (b *batchSentry) Emit(sjn metrics.SentryJSON) error {
+	b.ml.Lock()
+	defer b.ml.Unlock()
+	b.data = append(b.data, sjn)
+	return nil
+}
+
+func (b *batchSentry) EmitBatch(sjns []metrics.SentryJSON) error {
+	b.ml.Lock()
+	defer b.ml.Unlock()
+	b.batches++
+	b.data = append(b.data, sjns...)
+	return nil
+}
+
+type failingSentry struct{}
+
+func (failingSentry) Emit(metrics.SentryJSON) error {
+	return errors.New("bad sentry")
+}
+
+// TestAsyncMasterTime validates that sentries receive the time entries were
+// emitted at rather than delivered at.
+func TestAsyncMasterTime(t *testing.T) {
+	batch := new(batchSentry)
+	master := metrics.NewAsync(metrics.AsyncConfig{FlushInterval: time.Hour}, batch)
+
+	before := time.Now()
+	master.Emit(metrics.With("id", 1))
+	emitted := time.Now()
+
+	time.Sleep(20 * time.Millisecond)
+	master.Close(context.Background())
+
+	if len(batch.data) != 1 || batch.data[0].Time.Before(before) || batch.data[0].Time.After(emitted) {
+		t.Fatalf("\t%s\tShould have used time of emit: %+v", failedMark, batch.data)
+	}
+	t.Logf("\t%s\tShould have used time of emit", succeedMark)
+
+	err := metrics.SinkError{Sink: batch, Entries: 2, Err: errors.New("disk full")}
+	if err.Error() != "metrics: sink *metrics_test.batchSentry failed for 2 entries: disk full" {
+		t.Fatalf("\t%s\tShould have formatted sink error: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have formatted sink error", succeedMark)
+}
+
+// TestAsyncMaster validates the delivery, failure isolation and drop
+// accounting of the AsyncMaster.
+func TestAsyncMaster(t *testing.T) {
+	var failures int
+	var fl sync.Mutex
+
+	batch := new(batchSentry)
+	master := metrics.NewAsync(metrics.AsyncConfig{
+		BufferSize:    4,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		OnError: func(error) {
+			fl.Lock()
+			failures++
+			fl.Unlock()
+		},
+	}, failingSentry{}, batch)
+
+	master.Close(context.Background())
+	if err := master.Emit(metrics.With("id", 1)); err != metrics.ErrClosed {
+		t.Fatalf("\t%s\tShould have failed to emit into closed master", failedMark)
+	}
+	t.Logf("\t%s\tShould have failed to emit into closed master", succeedMark)
+
+	batch = new(batchSentry)
+	master = metrics.NewAsync(metrics.AsyncConfig{
+		BufferSize:    4,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		OnError: func(error) {
+			fl.Lock()
+			failures++
+			fl.Unlock()
+		},
+	}, failingSentry{}, batch)
+
+	for i := 0; i < 3; i++ {
+		master.Emit(metrics.With("id", i).WithMessage("entry"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := master.Flush(ctx); err != nil {
+		t.Fatalf("\t%s\tShould have flushed all entries: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have flushed all entries", succeedMark)
+
+	batch.ml.Lock()
+	total, batches := len(batch.data), batch.batches
+	batch.ml.Unlock()
+
+	if total != 3 {
+		t.Fatalf("\t%s\tShould have delivered 3 entries to batch sentry: %d", failedMark, total)
+	}
+	t.Logf("\t%s\tShould have delivered 3 entries to batch sentry", succeedMark)
+
+	if batches < 2 {
+		t.Fatalf("\t%s\tShould have delivered entries in batches of 2: %d", failedMark, batches)
+	}
+	t.Logf("\t%s\tShould have delivered entries in batches of 2", succeedMark)
+
+	fl.Lock()
+	failed := failures
+	fl.Unlock()
+
+	if failed != 3 || master.Failed() != 3 {
+		t.Fatalf("\t%s\tShould have reported 3 failures from failing sentry: %d", failedMark, failed)
+	}
+	t.Logf("\t%s\tShould have reported 3 failures from failing sentry", succeedMark)
+
+	if err := master.Close(ctx); err != nil {
+		t.Fatalf("\t%s\tShould have closed master: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have closed master", succeedMark)
+}
+
+// TestAsyncMasterDrops validates that entries beyond the buffer size are
+// dropped and counted.
+func TestAsyncMasterDrops(t *testing.T) {
+	block := make(chan struct{})
+	master := metrics.NewAsync(metrics.AsyncConfig{
+		BufferSize:    2,
+		BatchSize:     8,
+		FlushInterval: time.Hour,
+	}, sentryFunc(func(metrics.SentryJSON) error {
+		<-block
+		return nil
+	}))
+
+	var dropped int
+	for i := 0; i < 5; i++ {
+		if err := master.Emit(metrics.With("id", i)); err == metrics.ErrBufferFull {
+			dropped++
+		}
+	}
+
+	close(block)
+
+	if dropped != 3 || master.Dropped() != 3 {
+		t.Fatalf("\t%s\tShould have dropped 3 entries: %d", failedMark, master.Dropped())
+	}
+	t.Logf("\t%s\tShould have dropped 3 entries", succeedMark)
+
+	master.Close(context.Background())
+}
+
+type sentryFunc func(metrics.SentryJSON) error
+
+func (fn sentryFunc) Emit(sjn metrics.SentryJSON) error {
+	return fn(sjn)
+}
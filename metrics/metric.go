@@ -4,9 +4,11 @@
 package metrics
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,6 +17,10 @@ const (
 	// TraceKey defines the key which is used to store the trace object.
 	TraceKey = "FuncTrace"
 
+	// LevelKey defines the field key which carries the log level of an entry,
+	// such as INFO or ERROR.
+	LevelKey = "LogKEY"
+
 	// DefaultMessage defines a default message used by SentryJSON where
 	// fields contains no messages to be used.
 	DefaultMessage = "No Message"
@@ -68,6 +74,24 @@ type SentryJSON struct {
 	Fields  Fields    `json:"fields"`
 }
 
+// ToSentryJSON returns a SentryJSON built from the giving Entry, using the
+// "message" field or DefaultMessage when the entry has no message.
+func ToSentryJSON(e Entry) SentryJSON {
+	var sentryJSON SentryJSON
+	sentryJSON.Fields = e.Fields()
+	sentryJSON.Time = time.Now()
+
+	if e.Message != "" {
+		sentryJSON.Message = e.Message
+	} else if mo, ok := sentryJSON.Fields["message"].(string); ok {
+		sentryJSON.Message = mo
+	} else {
+		sentryJSON.Message = DefaultMessage
+	}
+
+	return sentryJSON
+}
+
 // SentryPipe defines a pipe which will expose a method to allow piping into a
 // metrics to deliver entries as centries.
 type SentryPipe struct {
@@ -84,20 +108,7 @@ func Sentries(sx ...Sentry) Metrics {
 
 // Emit delivers the giving entry to all available metricss.
 func (pipe SentryPipe) Emit(e Entry) error {
-	var sentryJSON SentryJSON
-	sentryJSON.Fields = e.Fields()
-	sentryJSON.Time = time.Now()
-
-	var message string
-	if e.Message != "" {
-		message = e.Message
-	} else if mo, ok := sentryJSON.Fields["message"].(string); ok {
-		message = mo
-	} else {
-		message = DefaultMessage
-	}
-
-	sentryJSON.Message = message
+	sentryJSON := ToSentryJSON(e)
 
 	for _, sentry := range pipe.sentries {
 		if err := sentry.Emit(sentryJSON); err != nil {
@@ -166,6 +177,10 @@ type Fields map[string]interface{}
 func (p *Pair) Fields() Fields {
 	var f Fields
 
+	if p == nil {
+		return make(Fields)
+	}
+
 	if p.prev == nil {
 		f = make(Fields)
 		f[p.key] = p.value
@@ -388,6 +403,42 @@ func (e Entry) WithFields(f Fields) Entry {
 
 //==============================================================================
 
+// PrintValue returns the string representation of a field value used by
+// sentries which write fields as text.
+func PrintValue(val interface{}) string {
+	switch bo := val.(type) {
+	case string:
+		return bo
+	case fmt.Stringer:
+		return bo.String()
+	case error:
+		return bo.Error()
+	case int:
+		return strconv.Itoa(bo)
+	case int64:
+		return strconv.FormatInt(bo, 10)
+	case rune:
+		return strconv.QuoteRune(bo)
+	case bool:
+		return strconv.FormatBool(bo)
+	case byte:
+		return strconv.QuoteRune(rune(bo))
+	case float64:
+		return strconv.FormatFloat(bo, 'f', 4, 64)
+	case float32:
+		return strconv.FormatFloat(float64(bo), 'f', 4, 64)
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "-"
+		}
+
+		return string(data)
+	}
+}
+
+//==============================================================================
+
 // Hide takes the given message and generates a '***' character sets.
 func Hide(message string) string {
 	mLen := len(message)
@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// contains the names of the builtin value scrubbers.
+const (
+	ScrubEmail = "email"
+	ScrubToken = "token"
+	ScrubCard  = "card"
+)
+
+// DefaultReplacement defines the text which replaces scrubbed values when a
+// ScrubRule provides none.
+const DefaultReplacement = "[REDACTED]"
+
+// builtinScrubbers contains the patterns for the builtin value scrubbers.
+var builtinScrubbers = map[string]string{
+	ScrubEmail: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	ScrubToken: `(?i)(?:bearer\s+[A-Za-z0-9\-._~+/]+=*|\b(?:api[_-]?key|access[_-]?token|token|secret|password)\s*[=:]\s*[^\s&,;]+)`,
+	ScrubCard:  `\b(?:\d[ \-]?){12,18}\d\b`,
+}
+
+//==============================================================================
+
+// ScrubRule defines a regular expression which is replaced in string values.
+type ScrubRule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// RedactConfig defines the rules applied by a Redactor to the fields and
+// message of every Entry.
+type RedactConfig struct {
+	// Redact contains key names or glob patterns, matched case-insensitively,
+	// whose values are masked with Hide.
+	Redact []string `json:"redact"`
+
+	// Drop contains key names or glob patterns whose fields are removed.
+	Drop []string `json:"drop"`
+
+	// Scrubbers contains the names of builtin scrubbers to apply, see
+	// ScrubEmail, ScrubToken and ScrubCard.
+	Scrubbers []string `json:"scrubbers"`
+
+	// Scrub contains custom scrub rules applied to string values.
+	Scrub []ScrubRule `json:"scrub"`
+
+	// MaxLength sets the max length of string values, longer values are
+	// truncated. Zero disables truncation.
+	MaxLength int `json:"max_length"`
+}
+
+// LoadRedactConfig returns a RedactConfig decoded from the json in the giving
+// reader.
+func LoadRedactConfig(r io.Reader) (RedactConfig, error) {
+	var config RedactConfig
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return config, fmt.Errorf("metrics: invalid redact config: %s", err)
+	}
+
+	return config, nil
+}
+
+//==============================================================================
+
+// scrubber defines a compiled ScrubRule.
+type scrubber struct {
+	rx          *regexp.Regexp
+	replacement string
+}
+
+// Redactor defines a Metrics middleware which filters the fields and message
+// of every Entry before delivering it to the next Metrics.
+type Redactor struct {
+	next      Metrics
+	redact    []string
+	drop      []string
+	scrubbers []scrubber
+	maxLength int
+}
+
+// NewRedactor returns a new Redactor which applies the giving config to all
+// entries delivered to next. It returns an error if a scrubber is unknown or
+// a pattern is invalid.
+func NewRedactor(config RedactConfig, next Metrics) (*Redactor, error) {
+	var rd Redactor
+	rd.next = next
+	rd.maxLength = config.MaxLength
+
+	for _, key := range config.Redact {
+		rd.redact = append(rd.redact, strings.ToLower(key))
+	}
+
+	for _, key := range config.Drop {
+		rd.drop = append(rd.drop, strings.ToLower(key))
+	}
+
+	for _, item := range append(rd.redact, rd.drop...) {
+		if _, err := path.Match(item, ""); err != nil {
+			return nil, fmt.Errorf("metrics: invalid key pattern %q: %s", item, err)
+		}
+	}
+
+	for _, name := range config.Scrubbers {
+		pattern, ok := builtinScrubbers[name]
+		if !ok {
+			return nil, fmt.Errorf("metrics: unknown scrubber %q", name)
+		}
+
+		rd.scrubbers = append(rd.scrubbers, scrubber{
+			rx:          regexp.MustCompile(pattern),
+			replacement: DefaultReplacement,
+		})
+	}
+
+	for _, rule := range config.Scrub {
+		rx, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: invalid scrub rule %q: %s", rule.Name, err)
+		}
+
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = DefaultReplacement
+		}
+
+		rd.scrubbers = append(rd.scrubbers, scrubber{rx: rx, replacement: replacement})
+	}
+
+	return &rd, nil
+}
+
+// Emit filters the giving entry and delivers it to the next Metrics.
+func (r *Redactor) Emit(e Entry) error {
+	return r.next.Emit(r.Entry(e))
+}
+
+// Entry returns a new Entry with the rules applied to its fields and message.
+func (r *Redactor) Entry(e Entry) Entry {
+	filtered := Entry{Message: r.value(e.Message)}
+
+	if e.Pair == nil {
+		return filtered
+	}
+
+	return filtered.WithFields(r.Fields(e.Fields()))
+}
+
+// Fields returns a copy of the giving fields with the rules applied, nested
+// maps are filtered recursively.
+func (r *Redactor) Fields(fields Fields) Fields {
+	filtered := make(Fields, len(fields))
+
+	for key, value := range fields {
+		if key == "" {
+			continue
+		}
+
+		name := strings.ToLower(key)
+
+		if matchKey(r.drop, name) {
+			continue
+		}
+
+		if matchKey(r.redact, name) {
+			filtered[key] = Hide(fmt.Sprintf("%v", value))
+			continue
+		}
+
+		filtered[key] = r.nested(value)
+	}
+
+	return filtered
+}
+
+// nested applies the rules to the giving value, recursing into maps, slices
+// and arrays. Errors, fmt.Stringers and structs are scrubbed in their string
+// form, while other values such as numbers are returned unchanged.
+func (r *Redactor) nested(value interface{}) interface{} {
+	switch bo := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.value(bo)
+	case []byte:
+		return r.value(string(bo))
+	case Fields:
+		return r.Fields(bo)
+	case map[string]interface{}:
+		return map[string]interface{}(r.Fields(Fields(bo)))
+	case map[string]string:
+		filtered := make(map[string]string, len(bo))
+		for key, val := range r.Fields(stringFields(bo)) {
+			filtered[key] = val.(string)
+		}
+		return filtered
+	case Trace, time.Time:
+		return value
+	case error:
+		return r.value(bo.Error())
+	case fmt.Stringer:
+		return r.value(bo.String())
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return value
+		}
+
+		filtered := make([]interface{}, rv.Len())
+		for i := range filtered {
+			filtered[i] = r.nested(rv.Index(i).Interface())
+		}
+		return filtered
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return value
+		}
+		return r.nested(rv.Elem().Interface())
+	case reflect.Struct:
+		return r.value(fmt.Sprintf("%+v", value))
+	default:
+		return value
+	}
+}
+
+// value applies the scrubbers and truncation to the giving string.
+func (r *Redactor) value(value string) string {
+	for _, sc := range r.scrubbers {
+		value = sc.rx.ReplaceAllString(value, sc.replacement)
+	}
+
+	if r.maxLength > 0 && len(value) > r.maxLength {
+		cut := r.maxLength
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+
+		value = value[:cut] + "...(truncated)"
+	}
+
+	return value
+}
+
+// matchKey returns true/false if the giving lowercased key matches any of
+// the patterns.
+func matchKey(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if pattern == key {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stringFields returns the giving string map as Fields.
+func stringFields(m map[string]string) Fields {
+	fields := make(Fields, len(m))
+	for key, val := range m {
+		fields[key] = val
+	}
+
+	return fields
+}
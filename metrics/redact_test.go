@@ -0,0 +1,184 @@
+package metrics_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+)
+
+type entryFunc func(metrics.Entry) error
+
+func (fn entryFunc) Emit(e metrics.Entry) error {
+	return fn(e)
+}
+
+// TestRedactor validates the redaction, dropping, scrubbing and truncation
+// applied by the Redactor.
+func TestRedactor(t *testing.T) {
+	config, err := metrics.LoadRedactConfig(strings.NewReader(`{
+		"redact": ["password", "*_secret"],
+		"drop": ["internal.*"],
+		"scrubbers": ["email", "card"],
+		"max_length": 12
+	}`))
+	if err != nil {
+		t.Fatalf("\t%s\tShould have loaded redact config: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have loaded redact config", succeedMark)
+
+	var received metrics.Entry
+
+	redactor, err := metrics.NewRedactor(config, entryFunc(func(e metrics.Entry) error {
+		received = e
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created redactor: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have created redactor", succeedMark)
+
+	entry := metrics.WithFields(metrics.Fields{
+		"Password":       "pass",
+		"client_secret":  "abcd",
+		"internal.trace": "xyz",
+		"contact":        "bob@mail.com",
+		"card":           "4111 1111 1111 1111",
+		"body":           "a very long body value",
+		"user":           map[string]interface{}{"password": "nested"},
+	}).WithMessage("signup from bob@mail.com")
+
+	redactor.Emit(entry)
+
+	fields := received.Fields()
+
+	if fields["Password"] != "****" || fields["client_secret"] != "****" {
+		t.Fatalf("\t%s\tShould have masked redacted keys: %+v", failedMark, fields)
+	}
+	t.Logf("\t%s\tShould have masked redacted keys", succeedMark)
+
+	if _, ok := fields["internal.trace"]; ok {
+		t.Fatalf("\t%s\tShould have dropped internal fields", failedMark)
+	}
+	t.Logf("\t%s\tShould have dropped internal fields", succeedMark)
+
+	if fields["contact"] != "[REDACTED]" || fields["card"] != "[REDACTED]" {
+		t.Fatalf("\t%s\tShould have scrubbed email and card values: %+v", failedMark, fields)
+	}
+	t.Logf("\t%s\tShould have scrubbed email and card values", succeedMark)
+
+	if fields["body"] != "a very long ...(truncated)" {
+		t.Fatalf("\t%s\tShould have truncated long values: %q", failedMark, fields["body"])
+	}
+	t.Logf("\t%s\tShould have truncated long values", succeedMark)
+
+	if user, ok := fields["user"].(map[string]interface{}); !ok || user["password"] != "******" {
+		t.Fatalf("\t%s\tShould have masked nested keys: %+v", failedMark, fields["user"])
+	}
+	t.Logf("\t%s\tShould have masked nested keys", succeedMark)
+
+	if strings.Contains(received.Message, "bob@mail.com") {
+		t.Fatalf("\t%s\tShould have scrubbed message: %q", failedMark, received.Message)
+	}
+	t.Logf("\t%s\tShould have scrubbed message", succeedMark)
+}
+
+type contact struct {
+	Name  string
+	Email string
+}
+
+type contactStringer struct {
+	Email string
+}
+
+func (c contactStringer) String() string {
+	return "contact " + c.Email
+}
+
+// TestRedactorValues validates the scrubbing of errors, stringers, slices,
+// arrays and structs by the Redactor.
+func TestRedactorValues(t *testing.T) {
+	redactor, err := metrics.NewRedactor(metrics.RedactConfig{
+		Scrubbers: []string{"email", "token"},
+	}, entryFunc(func(metrics.Entry) error { return nil }))
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created redactor: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have created redactor", succeedMark)
+
+	entry := redactor.Entry(metrics.WithFields(metrics.Fields{
+		"err":      errors.New("login failed with password=hunter2"),
+		"stringer": contactStringer{Email: "bob@mail.com"},
+		"emails":   []string{"bob@mail.com", "ana"},
+		"nested":   []interface{}{[2]string{"x", "ana@mail.com"}, 3},
+		"contact":  contact{Name: "bob", Email: "bob@mail.com"},
+		"pointer":  &contact{Name: "ana", Email: "ana@mail.com"},
+		"count":    4,
+	}))
+
+	fields := entry.Fields()
+
+	if _, ok := fields[""]; ok {
+		t.Fatalf("\t%s\tShould not have added an empty field: %+v", failedMark, fields)
+	}
+	t.Logf("\t%s\tShould not have added an empty field", succeedMark)
+
+	if fields["err"] != "login failed with [REDACTED]" {
+		t.Fatalf("\t%s\tShould have scrubbed error value: %+v", failedMark, fields["err"])
+	}
+	t.Logf("\t%s\tShould have scrubbed error value", succeedMark)
+
+	if fields["stringer"] != "contact [REDACTED]" {
+		t.Fatalf("\t%s\tShould have scrubbed stringer value: %+v", failedMark, fields["stringer"])
+	}
+	t.Logf("\t%s\tShould have scrubbed stringer value", succeedMark)
+
+	if !reflect.DeepEqual(fields["emails"], []interface{}{"[REDACTED]", "ana"}) {
+		t.Fatalf("\t%s\tShould have scrubbed slice values: %+v", failedMark, fields["emails"])
+	}
+	t.Logf("\t%s\tShould have scrubbed slice values", succeedMark)
+
+	if !reflect.DeepEqual(fields["nested"], []interface{}{[]interface{}{"x", "[REDACTED]"}, 3}) {
+		t.Fatalf("\t%s\tShould have scrubbed nested array values: %+v", failedMark, fields["nested"])
+	}
+	t.Logf("\t%s\tShould have scrubbed nested array values", succeedMark)
+
+	if fields["contact"] != "{Name:bob Email:[REDACTED]}" || fields["pointer"] != "{Name:ana Email:[REDACTED]}" {
+		t.Fatalf("\t%s\tShould have scrubbed struct values: %+v %+v", failedMark, fields["contact"], fields["pointer"])
+	}
+	t.Logf("\t%s\tShould have scrubbed struct values", succeedMark)
+
+	if fields["count"] != 4 {
+		t.Fatalf("\t%s\tShould have kept numeric values: %+v", failedMark, fields["count"])
+	}
+	t.Logf("\t%s\tShould have kept numeric values", succeedMark)
+
+	if empty := redactor.Entry(metrics.Entry{Message: "no fields"}); len(empty.Fields()) != 0 {
+		t.Fatalf("\t%s\tShould have kept entry without fields empty: %+v", failedMark, empty.Fields())
+	}
+	t.Logf("\t%s\tShould have kept entry without fields empty", succeedMark)
+}
+
+// TestPrintValue validates the text form of field values used by sentries.
+func TestPrintValue(t *testing.T) {
+	values := map[string]interface{}{
+		"text":   "text",
+		"12":     12,
+		"1.5000": 1.5,
+		"true":   true,
+		"failed": errors.New("failed"),
+		"1s":     time.Second,
+		`[1,2]`:  []int{1, 2},
+	}
+
+	for expected, value := range values {
+		if got := metrics.PrintValue(value); got != expected {
+			t.Fatalf("\t%s\tShould have printed %#v as %q: %q", failedMark, value, expected, got)
+		}
+	}
+	t.Logf("\t%s\tShould have printed field values", succeedMark)
+}
@@ -1,58 +1,494 @@
 package file
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/influx6/faux/metrics"
 )
 
-// File defines a struct which implements a memory collector for metricss.
+// ErrClosed is returned when entries are emitted into a closed File.
+var ErrClosed = errors.New("file: sentry is closed")
+
+// contains suffixes and formats used for rotated files.
+const (
+	rotateTimeFormat = "20060102T150405.000000000"
+	compressSuffix   = ".gz"
+)
+
+//==============================================================================
+
+// SyncPolicy defines how often the File syncs written entries to disk.
+type SyncPolicy int
+
+// contains the different sync policies supported by the File.
+const (
+	// SyncEveryEntry syncs the file after every emitted entry or batch.
+	SyncEveryEntry SyncPolicy = iota
+
+	// SyncInterval syncs the file at most once every Config.SyncEvery, with
+	// entries left unsynced by the last write synced after the interval even
+	// if no other entry is written.
+	SyncInterval
+
+	// SyncNever leaves syncing to the operating system.
+	SyncNever
+)
+
+// Config defines the rotation, retention and sync configuration for a File.
+type Config struct {
+	// Path sets the path of the active log file.
+	Path string
+
+	// MaxSize sets the size in bytes after which the file is rotated. Zero
+	// disables size based rotation.
+	MaxSize int64
+
+	// RotateEvery sets the age after which the file is rotated. Zero disables
+	// time based rotation.
+	RotateEvery time.Duration
+
+	// MaxBackups sets the max number of rotated files to retain. Zero retains
+	// all rotated files.
+	MaxBackups int
+
+	// MaxAge sets the max age of rotated files to retain. Zero retains rotated
+	// files regardless of age.
+	MaxAge time.Duration
+
+	// Compress sets if rotated files should be compressed with gzip.
+	Compress bool
+
+	// Sync sets the policy used to sync written entries to disk.
+	Sync SyncPolicy
+
+	// SyncEvery sets the interval used by the SyncInterval policy.
+	SyncEvery time.Duration
+
+	// ReopenOnSIGHUP sets if the file should be reopened when the process
+	// receives a SIGHUP, which allows external tools like logrotate to move it.
+	ReopenOnSIGHUP bool
+}
+
+//==============================================================================
+
+// File defines a struct which implements a rotating file collector for metricss.
 type File struct {
-	wl   sync.Mutex
-	file *os.File
-	path string
+	config Config
+
+	wl       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	syncedAt time.Time
+	dirty    bool
+	closed   bool
+
+	ml      sync.Mutex
+	wg      sync.WaitGroup
+	signals chan os.Signal
+	done    chan struct{}
 }
 
-// New returns a new instance of a File sentry.
+// New returns a new instance of a File sentry which syncs every entry and
+// never rotates.
 func New(path string) *File {
+	return NewWithConfig(Config{Path: path})
+}
+
+// NewWithConfig returns a new instance of a File sentry using the provided
+// Config.
+func NewWithConfig(config Config) *File {
 	var fm File
-	fm.path = path
+	fm.config = config
+
+	if config.Sync == SyncInterval && config.SyncEvery <= 0 {
+		fm.config.SyncEvery = time.Second
+	}
+
+	if config.Sync == SyncInterval {
+		fm.done = make(chan struct{})
+
+		fm.wg.Add(1)
+		go fm.syncEvery()
+	}
+
+	if config.ReopenOnSIGHUP {
+		fm.signals = make(chan os.Signal, 1)
+		signal.Notify(fm.signals, syscall.SIGHUP)
+
+		fm.wg.Add(1)
+		go fm.watchSignals()
+	}
+
 	return &fm
 }
 
-// Emit adds the giving SentryJSON into the internal slice.
+// Emit adds the giving SentryJSON into the file.
 func (f *File) Emit(sjn metrics.SentryJSON) error {
+	return f.EmitBatch([]metrics.SentryJSON{sjn})
+}
+
+// EmitBatch adds all giving SentryJSON values into the file, syncing once for
+// the whole batch.
+func (f *File) EmitBatch(sjns []metrics.SentryJSON) error {
 	f.wl.Lock()
 	defer f.wl.Unlock()
 
-	if f.file == nil {
-		fm, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE, 0600)
+	if f.closed {
+		return ErrClosed
+	}
+
+	for index := range sjns {
+		data, err := json.Marshal(&sjns[index])
+		if err != nil {
+			return err
+		}
+
+		data = append(data, '\r', '\n')
+
+		if err := f.rotateIfNeeded(int64(len(data))); err != nil {
+			return err
+		}
+
+		if err := f.openIfNeeded(); err != nil {
+			return err
+		}
+
+		written, err := f.file.Write(data)
+		f.size += int64(written)
+		f.dirty = true
+
 		if err != nil {
+			f.closeFile()
 			return err
 		}
+	}
+
+	return f.sync()
+}
+
+// Rotate forces the rotation of the current file.
+func (f *File) Rotate() error {
+	f.wl.Lock()
+	defer f.wl.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+
+	return f.rotate()
+}
+
+// Reopen closes the current file handle so the next write opens the file at
+// the configured path again.
+func (f *File) Reopen() error {
+	f.wl.Lock()
+	defer f.wl.Unlock()
+
+	return f.closeFile()
+}
+
+// Close syncs and closes the file, and waits for pending compression and
+// cleanup of rotated files to finish.
+func (f *File) Close() error {
+	f.wl.Lock()
+	if f.closed {
+		f.wl.Unlock()
+		return ErrClosed
+	}
+
+	f.closed = true
+	err := f.closeFile()
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+	}
+
+	if f.done != nil {
+		close(f.done)
+	}
+	f.wl.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// watchSignals reopens the file on every received SIGHUP.
+func (f *File) watchSignals() {
+	defer f.wg.Done()
+
+	for range f.signals {
+		f.Reopen()
+	}
+}
+
+// syncEvery syncs entries left unsynced by the SyncInterval policy once every
+// Config.SyncEvery until the file is closed.
+func (f *File) syncEvery() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.config.SyncEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			f.wl.Lock()
+			if !f.closed && f.file != nil && f.dirty {
+				if err := f.file.Sync(); err == nil {
+					f.syncedAt = time.Now()
+					f.dirty = false
+				}
+			}
+			f.wl.Unlock()
+		}
+	}
+}
 
-		f.file = fm
+// openIfNeeded opens the file for appending if no handle exists.
+func (f *File) openIfNeeded() error {
+	if f.file != nil {
+		return nil
 	}
 
-	if err := json.NewEncoder(f.file).Encode(&sjn); err != nil {
-		f.file.Close()
-		f.file = nil
+	fm, err := os.OpenFile(f.config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
 		return err
 	}
 
-	if _, err := f.file.Write([]byte("\r\n")); err != nil {
-		f.file.Close()
-		f.file = nil
+	stat, err := fm.Stat()
+	if err != nil {
+		fm.Close()
 		return err
 	}
 
+	f.file = fm
+	f.size = stat.Size()
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+// closeFile syncs and closes the current file handle if any.
+func (f *File) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	defer func() { f.file, f.dirty = nil, false }()
+
+	if f.config.Sync != SyncNever {
+		if err := f.file.Sync(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+
+	return f.file.Close()
+}
+
+// sync applies the configured SyncPolicy.
+func (f *File) sync() error {
+	if f.file == nil {
+		return nil
+	}
+
+	switch f.config.Sync {
+	case SyncNever:
+		return nil
+	case SyncInterval:
+		if time.Since(f.syncedAt) < f.config.SyncEvery {
+			return nil
+		}
+	}
+
 	if err := f.file.Sync(); err != nil {
-		f.file.Close()
-		f.file = nil
+		f.closeFile()
+		return err
+	}
+
+	f.syncedAt = time.Now()
+	f.dirty = false
+	return nil
+}
+
+// rotateIfNeeded rotates the file if writing the next amount of bytes would
+// exceed the max size or if the file is older than the rotation period and not
+// empty.
+func (f *File) rotateIfNeeded(next int64) error {
+	if f.file == nil {
+		if err := f.openIfNeeded(); err != nil {
+			return err
+		}
+	}
+
+	if f.config.MaxSize > 0 && f.size > 0 && f.size+next > f.config.MaxSize {
+		return f.rotate()
+	}
+
+	if f.config.RotateEvery > 0 && time.Since(f.openedAt) >= f.config.RotateEvery {
+		// An empty file is kept rather than rotated into an empty backup.
+		if f.size == 0 {
+			f.openedAt = time.Now()
+			return nil
+		}
+
+		return f.rotate()
+	}
+
+	return nil
+}
+
+// rotate moves the current file to a timestamped backup and schedules the
+// compression and cleanup of backups.
+func (f *File) rotate() error {
+	if err := f.closeFile(); err != nil {
 		return err
 	}
 
+	if _, err := os.Stat(f.config.Path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	// The stamp is in UTC as it is parsed back without a zone.
+	backup := f.config.Path + "." + time.Now().UTC().Format(rotateTimeFormat)
+	if err := os.Rename(f.config.Path, backup); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go f.mill(backup)
+
 	return nil
 }
+
+// mill compresses the giving backup if required and removes backups which
+// exceed the retention limits.
+func (f *File) mill(backup string) {
+	defer f.wg.Done()
+
+	f.ml.Lock()
+	defer f.ml.Unlock()
+
+	if f.config.Compress {
+		if err := compress(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+
+	if f.config.MaxBackups <= 0 && f.config.MaxAge <= 0 {
+		return
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-f.config.MaxAge)
+
+	for index, item := range backups {
+		if f.config.MaxBackups > 0 && index >= f.config.MaxBackups {
+			os.Remove(item.path)
+			continue
+		}
+
+		if f.config.MaxAge > 0 && item.stamp.Before(cutoff) {
+			os.Remove(item.path)
+		}
+	}
+}
+
+// backup defines a rotated file and its rotation time.
+type backup struct {
+	path  string
+	stamp time.Time
+}
+
+// backups returns all rotated files for the configured path, newest first.
+func (f *File) backups() ([]backup, error) {
+	dir := filepath.Dir(f.config.Path)
+	prefix := filepath.Base(f.config.Path) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []backup
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix)
+
+		rotatedAt, err := time.Parse(rotateTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+
+		items = append(items, backup{
+			path:  filepath.Join(dir, name),
+			stamp: rotatedAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].stamp.After(items[j].stamp)
+	})
+
+	return items, nil
+}
+
+// compress writes a gzip compressed copy of the giving file.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+
+	return dst.Close()
+}
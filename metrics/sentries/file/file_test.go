@@ -0,0 +1,363 @@
+package file_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/file"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+// TestFileRotation validates the size based rotation, compression and
+// retention of the File sentry.
+func TestFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	fs := file.NewWithConfig(file.Config{
+		Path:       path,
+		MaxSize:    200,
+		MaxBackups: 2,
+		Compress:   true,
+		Sync:       file.SyncNever,
+	})
+
+	for i := 0; i < 20; i++ {
+		if err := fs.Emit(metrics.SentryJSON{Time: time.Now(), Message: "rotating entry"}); err != nil {
+			t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+		}
+
+		// ensure rotated files receive distinct timestamps.
+		time.Sleep(time.Millisecond)
+	}
+	t.Logf("\t%s\tShould have emitted entry", succeedMark)
+
+	if err := fs.Close(); err != nil {
+		t.Fatalf("\t%s\tShould have closed file sentry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have closed file sentry", succeedMark)
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have active log file: %s", failedMark, err)
+	}
+
+	if stat.Size() > 200 {
+		t.Fatalf("\t%s\tShould have active log file within max size: %d", failedMark, stat.Size())
+	}
+	t.Logf("\t%s\tShould have active log file within max size", succeedMark)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have read log directory: %s", failedMark, err)
+	}
+
+	var backups int
+	for _, entry := range entries {
+		if entry.Name() == "app.log" {
+			continue
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".gz") {
+			t.Fatalf("\t%s\tShould have compressed rotated file: %s", failedMark, entry.Name())
+		}
+
+		backups++
+	}
+	t.Logf("\t%s\tShould have compressed rotated files", succeedMark)
+
+	if backups != 2 {
+		t.Fatalf("\t%s\tShould have retained 2 rotated files: %d", failedMark, backups)
+	}
+	t.Logf("\t%s\tShould have retained 2 rotated files", succeedMark)
+}
+
+// readLines returns the lines of the file at the giving path, decompressing
+// it if it is gzipped.
+func readLines(t *testing.T, path string) []string {
+	src, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have opened log file: %s", failedMark, err)
+	}
+	defer src.Close()
+
+	var reader io.Reader = src
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			t.Fatalf("\t%s\tShould have read gzip file: %s", failedMark, err)
+		}
+		reader = gz
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have read log file: %s", failedMark, err)
+	}
+
+	return strings.Fields(string(data))
+}
+
+// rotated returns the paths of the rotated files in the giving directory.
+func rotated(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have read log directory: %s", failedMark, err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	return paths
+}
+
+// TestFileConcurrentEmit validates that parallel emits across rotations
+// neither lose nor split entries, run it with -race.
+func TestFileConcurrentEmit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	fs := file.NewWithConfig(file.Config{
+		Path:      path,
+		MaxSize:   1024,
+		Sync:      file.SyncInterval,
+		SyncEvery: time.Millisecond,
+	})
+
+	const workers, emits = 8, 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < emits; j++ {
+				if err := fs.Emit(metrics.SentryJSON{Message: fmt.Sprintf("w%d-%d", i, j)}); err != nil {
+					t.Errorf("\t%s\tShould have emitted entry: %s", failedMark, err)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	fs.Close()
+
+	backups := rotated(t, dir)
+	if len(backups) == 0 {
+		t.Fatalf("\t%s\tShould have rotated during emits", failedMark)
+	}
+	t.Logf("\t%s\tShould have rotated during emits", succeedMark)
+
+	seen := make(map[string]bool)
+	for _, item := range append(backups, path) {
+		for _, line := range readLines(t, item) {
+			var sjn metrics.SentryJSON
+			if err := json.Unmarshal([]byte(line), &sjn); err != nil {
+				t.Fatalf("\t%s\tShould have written whole entries: %q", failedMark, line)
+			}
+			seen[sjn.Message] = true
+		}
+	}
+
+	if len(seen) != workers*emits {
+		t.Fatalf("\t%s\tShould have written all entries: %d", failedMark, len(seen))
+	}
+	t.Logf("\t%s\tShould have written all entries", succeedMark)
+}
+
+// TestFileTimeRotation validates the time based rotation and the age based
+// retention of the File sentry.
+func TestFileTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	// A backup older than MaxAge must be removed on the next rotation.
+	stale := path + "." + time.Now().UTC().Add(-48*time.Hour).Format("20060102T150405.000000000") + ".gz"
+	if err := os.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatalf("\t%s\tShould have written stale backup: %s", failedMark, err)
+	}
+
+	fs := file.NewWithConfig(file.Config{
+		Path:        path,
+		RotateEvery: 30 * time.Millisecond,
+		MaxAge:      24 * time.Hour,
+		Compress:    true,
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := fs.Emit(metrics.SentryJSON{Message: fmt.Sprintf("entry-%d", i)}); err != nil {
+			t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+		}
+
+		time.Sleep(40 * time.Millisecond)
+	}
+
+	fs.Close()
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("\t%s\tShould have removed backup older than max age", failedMark)
+	}
+	t.Logf("\t%s\tShould have removed backup older than max age", succeedMark)
+
+	backups := rotated(t, dir)
+	if len(backups) != 2 {
+		t.Fatalf("\t%s\tShould have rotated once per period: %+q", failedMark, backups)
+	}
+	t.Logf("\t%s\tShould have rotated once per period", succeedMark)
+
+	for _, item := range backups {
+		if !strings.HasSuffix(item, ".gz") || len(readLines(t, item)) != 1 {
+			t.Fatalf("\t%s\tShould have written a single entry into compressed backup: %s", failedMark, item)
+		}
+	}
+	t.Logf("\t%s\tShould have written no empty backups", succeedMark)
+
+	if lines := readLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], "entry-2") {
+		t.Fatalf("\t%s\tShould have written latest entry into active file: %+q", failedMark, lines)
+	}
+	t.Logf("\t%s\tShould have written latest entry into active file", succeedMark)
+}
+
+// TestFileBackupZone validates the age based retention of the File sentry
+// outside of UTC.
+func TestFileBackupZone(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+
+	time.Local = time.FixedZone("UTC-10", -10*60*60)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	stale := path + "." + time.Now().UTC().Add(-3*time.Hour).Format("20060102T150405.000000000")
+	if err := os.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatalf("\t%s\tShould have written stale backup: %s", failedMark, err)
+	}
+
+	fs := file.NewWithConfig(file.Config{
+		Path:   path,
+		MaxAge: 2 * time.Hour,
+		Sync:   file.SyncNever,
+	})
+
+	if err := fs.Emit(metrics.SentryJSON{Message: "entry"}); err != nil {
+		t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+	}
+
+	if err := fs.Rotate(); err != nil {
+		t.Fatalf("\t%s\tShould have rotated file: %s", failedMark, err)
+	}
+
+	fs.Close()
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("\t%s\tShould have removed backup older than max age", failedMark)
+	}
+	t.Logf("\t%s\tShould have removed backup older than max age", succeedMark)
+
+	if backups := rotated(t, dir); len(backups) != 1 {
+		t.Fatalf("\t%s\tShould have kept new backup outside of UTC: %+q", failedMark, backups)
+	}
+	t.Logf("\t%s\tShould have kept new backup outside of UTC", succeedMark)
+}
+
+// TestFileSyncInterval validates the File sentry syncs and closes with the
+// SyncInterval policy.
+func TestFileSyncInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	fs := file.NewWithConfig(file.Config{
+		Path:      path,
+		Sync:      file.SyncInterval,
+		SyncEvery: 10 * time.Millisecond,
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := fs.Emit(metrics.SentryJSON{Message: fmt.Sprintf("entry-%d", i)}); err != nil {
+			t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if err := fs.Close(); err != nil {
+		t.Fatalf("\t%s\tShould have closed file: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have closed file", succeedMark)
+
+	if lines := readLines(t, path); len(lines) != 2 {
+		t.Fatalf("\t%s\tShould have written all entries: %+q", failedMark, lines)
+	}
+	t.Logf("\t%s\tShould have written all entries", succeedMark)
+}
+
+// TestFileReopen validates the reopening of a file moved away by an external
+// tool, both directly and on SIGHUP.
+func TestFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	fs := file.NewWithConfig(file.Config{Path: path, ReopenOnSIGHUP: true, Sync: file.SyncNever})
+	defer fs.Close()
+
+	fs.Emit(metrics.SentryJSON{Message: "before"})
+
+	if err := os.Rename(path, path+".moved"); err != nil {
+		t.Fatalf("\t%s\tShould have moved log file: %s", failedMark, err)
+	}
+
+	if err := fs.Reopen(); err != nil {
+		t.Fatalf("\t%s\tShould have reopened file: %s", failedMark, err)
+	}
+
+	fs.Emit(metrics.SentryJSON{Message: "after"})
+
+	if lines := readLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], "after") {
+		t.Fatalf("\t%s\tShould have written into reopened file: %+q", failedMark, lines)
+	}
+	t.Logf("\t%s\tShould have written into reopened file", succeedMark)
+
+	if err := os.Rename(path, path+".moved"); err != nil {
+		t.Fatalf("\t%s\tShould have moved log file: %s", failedMark, err)
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("\t%s\tShould have sent SIGHUP: %s", failedMark, err)
+	}
+
+	// The reopen happens in the background, so emit until the file returns.
+	deadline := time.Now().Add(time.Second)
+	for {
+		fs.Emit(metrics.SentryJSON{Message: "signaled"})
+
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("\t%s\tShould have reopened file on SIGHUP", failedMark)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Logf("\t%s\tShould have reopened file on SIGHUP", succeedMark)
+}
@@ -0,0 +1,259 @@
+// Package gelf provides a metrics.Sentry which delivers entries to Graylog
+// using the GELF 1.1 format over udp, with chunking and compression, or tcp.
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/syslog"
+)
+
+// contains the limits defined by the GELF chunking specification.
+const (
+	// DefaultChunkSize defines the default max size of a udp chunk.
+	DefaultChunkSize = 1420
+
+	// MaxChunks defines the max number of chunks a single message can use.
+	MaxChunks = 128
+
+	chunkHeaderSize = 12
+)
+
+// ErrTooManyChunks is returned when a message requires more than MaxChunks.
+var ErrTooManyChunks = errors.New("gelf: message requires too many chunks")
+
+// chunkMagic defines the magic bytes which start every chunk.
+var chunkMagic = []byte{0x1e, 0x0f}
+
+// Config defines the configuration for a GELF sentry.
+type Config struct {
+	// Network sets the network used to dial, either "udp" or "tcp".
+	Network string
+
+	// Addr sets the address of the Graylog input.
+	Addr string
+
+	// Host sets the host field of messages, os.Hostname by default.
+	Host string
+
+	// ChunkSize sets the max size of udp chunks, DefaultChunkSize by default.
+	ChunkSize int
+
+	// Compress sets if udp messages should be gzip compressed.
+	Compress bool
+}
+
+// GELF defines a metrics.Sentry which writes entries to a Graylog input.
+type GELF struct {
+	config Config
+
+	ml   sync.Mutex
+	conn net.Conn
+}
+
+// New returns a new GELF sentry with the provided Config. The connection is
+// established on the first emitted entry.
+func New(config Config) *GELF {
+	if config.Network == "" {
+		config.Network = "udp"
+	}
+
+	if config.Host == "" {
+		config.Host, _ = os.Hostname()
+	}
+
+	if config.ChunkSize <= chunkHeaderSize {
+		config.ChunkSize = DefaultChunkSize
+	}
+
+	return &GELF{config: config}
+}
+
+// Emit encodes the giving SentryJSON as a GELF message and writes it to the
+// Graylog input.
+func (g *GELF) Emit(sjn metrics.SentryJSON) error {
+	data, err := g.Encode(sjn)
+	if err != nil {
+		return err
+	}
+
+	g.ml.Lock()
+	defer g.ml.Unlock()
+
+	if g.conn == nil {
+		conn, err := net.Dial(g.config.Network, g.config.Addr)
+		if err != nil {
+			return err
+		}
+
+		g.conn = conn
+	}
+
+	if err := g.write(data); err != nil {
+		g.conn.Close()
+		g.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection to the Graylog input.
+func (g *GELF) Close() error {
+	g.ml.Lock()
+	defer g.ml.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
+
+// Encode returns the GELF json representation of the giving SentryJSON.
+func (g *GELF) Encode(sjn metrics.SentryJSON) ([]byte, error) {
+	message := map[string]interface{}{
+		"version":       "1.1",
+		"host":          g.config.Host,
+		"short_message": sjn.Message,
+		"timestamp":     float64(sjn.Time.UnixNano()) / 1e9,
+		"level":         int(syslog.SeverityFor(sjn)),
+	}
+
+	for key, value := range sjn.Fields {
+		if value == nil {
+			continue
+		}
+
+		name := fieldName(key)
+		if name == "" {
+			continue
+		}
+
+		switch bo := value.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			message[name] = bo
+		case fmt.Stringer:
+			message[name] = bo.String()
+		case error:
+			message[name] = bo.Error()
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				message[name] = fmt.Sprintf("%+v", value)
+				continue
+			}
+
+			message[name] = string(data)
+		}
+	}
+
+	return json.Marshal(message)
+}
+
+// write delivers the encoded message using the configured network framing.
+func (g *GELF) write(data []byte) error {
+	if g.config.Network != "udp" && g.config.Network != "udp4" && g.config.Network != "udp6" {
+		_, err := g.conn.Write(append(data, 0))
+		return err
+	}
+
+	if g.config.Compress {
+		var bu bytes.Buffer
+		gz := gzip.NewWriter(&bu)
+
+		if _, err := gz.Write(data); err != nil {
+			return err
+		}
+
+		if err := gz.Close(); err != nil {
+			return err
+		}
+
+		data = bu.Bytes()
+	}
+
+	chunks, err := Chunk(data, g.config.ChunkSize)
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks {
+		if _, err := g.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Chunk splits the giving message into GELF chunks no larger than size. A
+// message which fits into a single datagram is returned unchanged.
+func Chunk(data []byte, size int) ([][]byte, error) {
+	if len(data) <= size {
+		return [][]byte{data}, nil
+	}
+
+	body := size - chunkHeaderSize
+	total := (len(data) + body - 1) / body
+	if total > MaxChunks {
+		return nil, ErrTooManyChunks
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, total)
+
+	for index := 0; index < total; index++ {
+		end := (index + 1) * body
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunk := make([]byte, 0, chunkHeaderSize+end-index*body)
+		chunk = append(chunk, chunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(index), byte(total))
+		chunk = append(chunk, data[index*body:end]...)
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+// fieldName returns the giving key as a GELF additional field name.
+func fieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '_', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = "_" + strings.TrimLeft(name, "_")
+	if name == "_" || name == "_id" {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,88 @@
+package gelf_test
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/gelf"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+// TestGELFChunking validates that large messages are chunked over udp and
+// can be reassembled into the original message.
+func TestGELFChunking(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created udp listener: %s", failedMark, err)
+	}
+	defer conn.Close()
+
+	gl := gelf.New(gelf.Config{
+		Addr:      conn.LocalAddr().String(),
+		Host:      "box",
+		ChunkSize: 100,
+	})
+	defer gl.Close()
+
+	err = gl.Emit(metrics.SentryJSON{
+		Time:    time.Now(),
+		Message: strings.Repeat("a", 300),
+		Fields:  metrics.Fields{"LogKEY": "DEBUG", "id": 20, "request": "r1"},
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have emitted entry", succeedMark)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var total int
+	parts := make(map[int][]byte)
+
+	for total == 0 || len(parts) < total {
+		data := make([]byte, 200)
+
+		n, _, err := conn.ReadFrom(data)
+		if err != nil {
+			t.Fatalf("\t%s\tShould have received chunk: %s", failedMark, err)
+		}
+
+		if n > 100 || data[0] != 0x1e || data[1] != 0x0f {
+			t.Fatalf("\t%s\tShould have received valid chunk of at most 100 bytes", failedMark)
+		}
+
+		total = int(data[11])
+		parts[int(data[10])] = data[12:n]
+	}
+	t.Logf("\t%s\tShould have received %d valid chunks", succeedMark, total)
+
+	var message []byte
+	for i := 0; i < total; i++ {
+		message = append(message, parts[i]...)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(message, &decoded); err != nil {
+		t.Fatalf("\t%s\tShould have reassembled json message: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have reassembled json message", succeedMark)
+
+	if decoded["version"] != "1.1" || decoded["level"] != float64(7) || decoded["_request"] != "r1" {
+		t.Fatalf("\t%s\tShould have received GELF fields: %+v", failedMark, decoded)
+	}
+	t.Logf("\t%s\tShould have received GELF fields", succeedMark)
+
+	if _, ok := decoded["_id"]; ok {
+		t.Fatalf("\t%s\tShould have skipped reserved _id field", failedMark)
+	}
+	t.Logf("\t%s\tShould have skipped reserved _id field", succeedMark)
+}
@@ -0,0 +1,174 @@
+// Package journald provides a metrics.Sentry which writes entries to the
+// systemd journal using its native protocol over a unix datagram socket.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/syslog"
+)
+
+// SocketPath defines the default path of the journald native socket.
+const SocketPath = "/run/systemd/journal/socket"
+
+// reserved contains the field names written by Encode for every entry.
+var reserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"SYSLOG_TIMESTAMP":  true,
+}
+
+// Journald defines a metrics.Sentry which writes entries to journald.
+type Journald struct {
+	path       string
+	identifier string
+
+	ml   sync.Mutex
+	conn *net.UnixConn
+}
+
+// New returns a new Journald sentry which writes to the socket at the giving
+// path, using SocketPath if empty. The identifier sets SYSLOG_IDENTIFIER and
+// defaults to the executable name.
+func New(path string, identifier string) *Journald {
+	if path == "" {
+		path = SocketPath
+	}
+
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	return &Journald{
+		path:       path,
+		identifier: identifier,
+	}
+}
+
+// Emit writes the giving SentryJSON as a single journal entry.
+func (j *Journald) Emit(sjn metrics.SentryJSON) error {
+	data := j.Encode(sjn)
+
+	j.ml.Lock()
+	defer j.ml.Unlock()
+
+	if j.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: j.path, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+
+		j.conn = conn
+	}
+
+	if _, err := j.conn.Write(data); err != nil {
+		j.conn.Close()
+		j.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection to the journald socket.
+func (j *Journald) Close() error {
+	j.ml.Lock()
+	defer j.ml.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+// Encode returns the giving SentryJSON encoded in the journald native
+// protocol format.
+func (j *Journald) Encode(sjn metrics.SentryJSON) []byte {
+	var bu bytes.Buffer
+
+	writeField(&bu, "MESSAGE", sjn.Message)
+	writeField(&bu, "PRIORITY", strconv.Itoa(int(syslog.SeverityFor(sjn))))
+	writeField(&bu, "SYSLOG_IDENTIFIER", j.identifier)
+	writeField(&bu, "SYSLOG_TIMESTAMP", sjn.Time.Format("2006-01-02T15:04:05.000000Z07:00"))
+
+	keys := make([]string, 0, len(sjn.Fields))
+	for key := range sjn.Fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := sjn.Fields[key]
+		if value == nil {
+			continue
+		}
+
+		name := FieldName(key)
+		if name == "" || reserved[name] {
+			continue
+		}
+
+		writeField(&bu, name, metrics.PrintValue(value))
+	}
+
+	return bu.Bytes()
+}
+
+// FieldName returns the giving key as a valid journal field name, which only
+// contains uppercase letters, digits and underscores and never starts with an
+// underscore. It returns an empty string if no valid name can be derived.
+func FieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return ""
+	}
+
+	if len(name) > 64 {
+		return name[:64]
+	}
+
+	return name
+}
+
+// writeField writes a single field, using the binary length prefixed form
+// when the value contains a newline.
+func writeField(bu *bytes.Buffer, name string, value string) {
+	if !strings.Contains(value, "\n") {
+		bu.WriteString(name)
+		bu.WriteByte('=')
+		bu.WriteString(value)
+		bu.WriteByte('\n')
+		return
+	}
+
+	bu.WriteString(name)
+	bu.WriteByte('\n')
+	binary.Write(bu, binary.LittleEndian, uint64(len(value)))
+	bu.WriteString(value)
+	bu.WriteByte('\n')
+}
@@ -0,0 +1,76 @@
+package journald_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/journald"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+// TestJournald validates the native protocol messages written to a journald
+// socket.
+func TestJournald(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created unixgram listener: %s", failedMark, err)
+	}
+	defer conn.Close()
+
+	jd := journald.New(path, "app")
+	defer jd.Close()
+
+	err = jd.Emit(metrics.SentryJSON{
+		Time:    time.Now(),
+		Message: "line one\nline two",
+		Fields:  metrics.Fields{"LogKEY": "NOTICE", "user-id": 20, "_hidden": "x", "message": "dup"},
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have emitted entry", succeedMark)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	data := make([]byte, 4096)
+	n, err := conn.Read(data)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have received datagram: %s", failedMark, err)
+	}
+	data = data[:n]
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len("line one\nline two")))
+
+	expected := [][]byte{
+		append(append([]byte("MESSAGE\n"), size...), []byte("line one\nline two\n")...),
+		[]byte("PRIORITY=5\n"),
+		[]byte("SYSLOG_IDENTIFIER=app\n"),
+		[]byte("USER_ID=20\n"),
+		[]byte("HIDDEN=x\n"),
+	}
+
+	for _, item := range expected {
+		if !bytes.Contains(data, item) {
+			t.Fatalf("\t%s\tShould have received field %q in %q", failedMark, item, data)
+		}
+	}
+	t.Logf("\t%s\tShould have received all fields", succeedMark)
+
+	if bytes.Contains(data, []byte("MESSAGE=dup")) {
+		t.Fatalf("\t%s\tShould have skipped reserved field names", failedMark)
+	}
+	t.Logf("\t%s\tShould have skipped reserved field names", succeedMark)
+}
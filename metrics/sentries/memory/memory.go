@@ -1,16 +1,340 @@
 package memory
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/influx6/faux/metrics"
 )
 
-// Memory defines a struct which implements a memory collector for metricss.
+// Memory defines a struct which implements a concurrency-safe memory
+// collector for metricss. The zero value keeps all entries.
 type Memory struct {
+	// Data holds the captured entries in the order received.
+	//
+	// Deprecated: Data is not safe to read while entries are emitted, use
+	// Entries instead.
 	Data []metrics.SentryJSON
+
+	ml     sync.Mutex
+	max    int
+	notify chan struct{}
+}
+
+// New returns a new Memory which keeps at most max entries, dropping the
+// oldest entries once full. A max of zero or less keeps all entries.
+func New(max int) *Memory {
+	return &Memory{max: max}
 }
 
-// Emit adds the giving SentryJSON into the internal slice.
+// Emit adds the giving SentryJSON into the internal buffer.
 func (m *Memory) Emit(sjn metrics.SentryJSON) error {
-	m.Data = append(m.Data, sjn)
+	return m.EmitBatch([]metrics.SentryJSON{sjn})
+}
+
+// EmitBatch adds all giving SentryJSON values into the internal buffer.
+func (m *Memory) EmitBatch(sjns []metrics.SentryJSON) error {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	for _, sjn := range sjns {
+		if m.max > 0 && len(m.Data) == m.max {
+			m.Data = append(m.Data[1:], sjn)
+			continue
+		}
+
+		m.Data = append(m.Data, sjn)
+	}
+
+	if m.notify != nil {
+		close(m.notify)
+		m.notify = nil
+	}
+
 	return nil
 }
+
+// Entries returns a copy of all captured entries in the order received.
+func (m *Memory) Entries() []metrics.SentryJSON {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	return m.entries()
+}
+
+// Len returns the number of captured entries.
+func (m *Memory) Len() int {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	return len(m.Data)
+}
+
+// Reset removes all captured entries.
+func (m *Memory) Reset() {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	m.Data = nil
+}
+
+// Find returns all captured entries which match all giving predicates.
+func (m *Memory) Find(preds ...Predicate) []metrics.SentryJSON {
+	var found []metrics.SentryJSON
+
+	for _, sjn := range m.Entries() {
+		if All(preds...).Match(sjn) {
+			found = append(found, sjn)
+		}
+	}
+
+	return found
+}
+
+// Count returns the number of captured entries which match all giving
+// predicates.
+func (m *Memory) Count(preds ...Predicate) int {
+	return len(m.Find(preds...))
+}
+
+// WaitFor blocks until an entry matching all giving predicates has been
+// captured or the context expires.
+func (m *Memory) WaitFor(ctx context.Context, preds ...Predicate) (metrics.SentryJSON, error) {
+	pred := All(preds...)
+
+	for {
+		m.ml.Lock()
+		for _, sjn := range m.entries() {
+			if pred.Match(sjn) {
+				m.ml.Unlock()
+				return sjn, nil
+			}
+		}
+
+		if m.notify == nil {
+			m.notify = make(chan struct{})
+		}
+
+		notify := m.notify
+		m.ml.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return metrics.SentryJSON{}, fmt.Errorf("memory: no entry matching %s: %s", pred.Desc, ctx.Err())
+		}
+	}
+}
+
+// entries returns a copy of the captured entries, expects lock to be held.
+func (m *Memory) entries() []metrics.SentryJSON {
+	items := make([]metrics.SentryJSON, len(m.Data))
+	copy(items, m.Data)
+	return items
+}
+
+//==============================================================================
+
+// TestingT defines the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertLogged reports a test error with a listing of captured entries if no
+// entry matches all giving predicates.
+func (m *Memory) AssertLogged(t TestingT, preds ...Predicate) bool {
+	t.Helper()
+
+	if m.Count(preds...) > 0 {
+		return true
+	}
+
+	t.Errorf("expected an entry matching %s\n%s", All(preds...).Desc, m.report(All(preds...)))
+	return false
+}
+
+// AssertNotLogged reports a test error with a listing of the offending
+// entries if any entry matches all giving predicates.
+func (m *Memory) AssertNotLogged(t TestingT, preds ...Predicate) bool {
+	t.Helper()
+
+	if m.Count(preds...) == 0 {
+		return true
+	}
+
+	t.Errorf("expected no entry matching %s\n%s", All(preds...).Desc, m.report(All(preds...)))
+	return false
+}
+
+// report returns a listing of all captured entries with a marker for
+// matching entries and the reason for entries which do not match.
+func (m *Memory) report(pred Predicate) string {
+	entries := m.Entries()
+
+	var bu bytes.Buffer
+	fmt.Fprintf(&bu, "captured %d entries:\n", len(entries))
+
+	for index, sjn := range entries {
+		mark := "-"
+		reason := pred.Check(sjn)
+		if reason == "" {
+			mark = "+"
+		}
+
+		fmt.Fprintf(&bu, "  %s [%d] %s %q %s", mark, index, sjn.Time.Format(time.RFC3339Nano), sjn.Message, printFields(sjn.Fields))
+
+		if reason != "" {
+			fmt.Fprintf(&bu, "\n      %s", reason)
+		}
+
+		bu.WriteByte('\n')
+	}
+
+	return bu.String()
+}
+
+// printFields returns the fields in sorted key=value form.
+func printFields(fields metrics.Fields) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		data, err := json.Marshal(fields[key])
+		if err != nil {
+			data = []byte(fmt.Sprintf("%+v", fields[key]))
+		}
+
+		pairs = append(pairs, key+"="+string(data))
+	}
+
+	return "{" + strings.Join(pairs, " ") + "}"
+}
+
+//==============================================================================
+
+// Predicate defines a described condition for captured entries. Check returns
+// an empty string when the entry matches, else the reason it does not.
+type Predicate struct {
+	Desc  string
+	Check func(metrics.SentryJSON) string
+}
+
+// Match returns true/false if the entry matches the predicate.
+func (p Predicate) Match(sjn metrics.SentryJSON) bool {
+	return p.Check(sjn) == ""
+}
+
+// All returns a Predicate which matches entries matching all giving
+// predicates.
+func All(preds ...Predicate) Predicate {
+	if len(preds) == 1 {
+		return preds[0]
+	}
+
+	descs := make([]string, 0, len(preds))
+	for _, pred := range preds {
+		descs = append(descs, pred.Desc)
+	}
+
+	desc := strings.Join(descs, " and ")
+	if desc == "" {
+		desc = "any entry"
+	}
+
+	return Predicate{
+		Desc: desc,
+		Check: func(sjn metrics.SentryJSON) string {
+			for _, pred := range preds {
+				if reason := pred.Check(sjn); reason != "" {
+					return reason
+				}
+			}
+
+			return ""
+		},
+	}
+}
+
+// Message returns a Predicate which matches entries whose message matches
+// the giving regular expression.
+func Message(pattern string) Predicate {
+	rx := regexp.MustCompile(pattern)
+
+	return Predicate{
+		Desc: fmt.Sprintf("message =~ %q", pattern),
+		Check: func(sjn metrics.SentryJSON) string {
+			if rx.MatchString(sjn.Message) {
+				return ""
+			}
+
+			return fmt.Sprintf("message: got %q, want match for %q", sjn.Message, pattern)
+		},
+	}
+}
+
+// Field returns a Predicate which matches entries whose field with the giving
+// key is deeply equal to value.
+func Field(key string, value interface{}) Predicate {
+	return Predicate{
+		Desc: fmt.Sprintf("field %s == %#v", key, value),
+		Check: func(sjn metrics.SentryJSON) string {
+			got, ok := sjn.Fields[key]
+			if !ok {
+				return fmt.Sprintf("field %s: missing, want %#v", key, value)
+			}
+
+			if !reflect.DeepEqual(got, value) {
+				return fmt.Sprintf("field %s: got %#v, want %#v", key, got, value)
+			}
+
+			return ""
+		},
+	}
+}
+
+// Level returns a Predicate which matches entries with the giving level, such
+// as INFO or ERROR.
+func Level(level string) Predicate {
+	return Predicate{
+		Desc: fmt.Sprintf("level == %s", level),
+		Check: func(sjn metrics.SentryJSON) string {
+			got, _ := sjn.Fields[metrics.LevelKey].(string)
+			if strings.EqualFold(got, level) {
+				return ""
+			}
+
+			return fmt.Sprintf("level: got %q, want %q", got, level)
+		},
+	}
+}
+
+// Between returns a Predicate which matches entries with a time within the
+// giving range, inclusive. A zero start or end leaves that side open.
+func Between(start, end time.Time) Predicate {
+	return Predicate{
+		Desc: fmt.Sprintf("time within [%s, %s]", start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano)),
+		Check: func(sjn metrics.SentryJSON) string {
+			if (!start.IsZero() && sjn.Time.Before(start)) || (!end.IsZero() && sjn.Time.After(end)) {
+				return fmt.Sprintf("time: got %s, want within range", sjn.Time.Format(time.RFC3339Nano))
+			}
+
+			return ""
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/memory"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+type recordT struct {
+	errors []string
+}
+
+func (r *recordT) Helper() {}
+
+func (r *recordT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestMemoryQueries validates the ring buffer cap and queries of the Memory
+// sentry.
+func TestMemoryQueries(t *testing.T) {
+	mem := memory.New(3)
+
+	for i := 0; i < 5; i++ {
+		mem.Emit(metrics.SentryJSON{
+			Time:    time.Now(),
+			Message: fmt.Sprintf("request %d", i),
+			Fields:  metrics.Fields{"LogKEY": "INFO", "id": i},
+		})
+	}
+
+	entries := mem.Entries()
+	if len(entries) != 3 || entries[0].Message != "request 2" || entries[2].Message != "request 4" {
+		t.Fatalf("\t%s\tShould have kept the 3 newest entries in order: %+v", failedMark, entries)
+	}
+	t.Logf("\t%s\tShould have kept the 3 newest entries in order", succeedMark)
+
+	if mem.Count(memory.Message(`request [34]`), memory.Level("info")) != 2 {
+		t.Fatalf("\t%s\tShould have found 2 entries by message and level", failedMark)
+	}
+	t.Logf("\t%s\tShould have found 2 entries by message and level", succeedMark)
+
+	rt := new(recordT)
+	if !mem.AssertLogged(rt, memory.Field("id", 3)) || mem.AssertLogged(rt, memory.Field("id", 1)) {
+		t.Fatalf("\t%s\tShould have asserted logged entries", failedMark)
+	}
+
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "field id: got 2, want 1") {
+		t.Fatalf("\t%s\tShould have reported mismatch reasons: %+v", failedMark, rt.errors)
+	}
+	t.Logf("\t%s\tShould have asserted logged entries", succeedMark)
+
+	if !mem.AssertNotLogged(rt, memory.Level("ERROR")) {
+		t.Fatalf("\t%s\tShould have asserted no error entries", failedMark)
+	}
+	t.Logf("\t%s\tShould have asserted no error entries", succeedMark)
+}
+
+// TestMemoryWaitFor validates that WaitFor blocks until a matching entry is
+// emitted from another goroutine.
+func TestMemoryWaitFor(t *testing.T) {
+	var mem memory.Memory
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mem.Emit(metrics.SentryJSON{Time: time.Now(), Message: "done"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := mem.WaitFor(ctx, memory.Message("^done$")); err != nil {
+		t.Fatalf("\t%s\tShould have waited for entry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have waited for entry", succeedMark)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := mem.WaitFor(ctx, memory.Message("never")); err == nil {
+		t.Fatalf("\t%s\tShould have timed out waiting for entry", failedMark)
+	}
+	t.Logf("\t%s\tShould have timed out waiting for entry", succeedMark)
+}
+
+// TestMemoryData validates the deprecated Data field still holds the captured
+// entries in order.
+func TestMemoryData(t *testing.T) {
+	var mem memory.Memory
+	mem.Emit(metrics.SentryJSON{Message: "first"})
+	mem.Emit(metrics.SentryJSON{Message: "second"})
+
+	if len(mem.Data) != 2 || mem.Data[0].Message != "first" || mem.Data[1].Message != "second" {
+		t.Fatalf("\t%s\tShould have kept entries in Data: %+v", failedMark, mem.Data)
+	}
+	t.Logf("\t%s\tShould have kept entries in Data", succeedMark)
+}
@@ -0,0 +1,351 @@
+package stdout
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/influx6/faux/metrics"
+	"github.com/mattn/go-isatty"
+)
+
+// contains the names used for the builtin values written by encoders.
+const (
+	TimeName    = "time"
+	LevelName   = "level"
+	MessageName = "msg"
+)
+
+//==============================================================================
+
+// Encoder defines an interface which writes a metrics.Entry into a writer in
+// a giving format. The colored flag reports if the writer supports colors.
+type Encoder interface {
+	Encode(w io.Writer, e metrics.Entry, colored bool) error
+}
+
+// Options defines the options shared by all encoders.
+type Options struct {
+	// TimeFormat sets the layout used for the timestamp. Logfmt and JSON use
+	// time.RFC3339 by default, while Console only writes a timestamp when a
+	// layout is set. Set to "-" to leave the timestamp out.
+	TimeFormat string
+
+	// Order sets the keys which are written first and in the giving order,
+	// all other keys follow in sorted order.
+	Order []string
+
+	// Rename maps keys, including TimeName, LevelName and MessageName, to
+	// the name written into the output.
+	Rename map[string]string
+}
+
+// key returns the output name for the giving key.
+func (o Options) key(name string) string {
+	if renamed, ok := o.Rename[name]; ok {
+		return renamed
+	}
+
+	return name
+}
+
+// timestamp returns the formatted time or false if time is disabled, where
+// the layout defaults to the giving one.
+func (o Options) timestamp(now time.Time, layout string) (string, bool) {
+	switch o.TimeFormat {
+	case "-":
+		return "", false
+	case "":
+		if layout == "" {
+			return "", false
+		}
+
+		return now.Format(layout), true
+	default:
+		return now.Format(o.TimeFormat), true
+	}
+}
+
+// keys returns the keys of the giving fields in the configured order.
+func (o Options) keys(fields metrics.Fields) []string {
+	seen := make(map[string]bool, len(o.Order))
+	keys := make([]string, 0, len(fields))
+
+	for _, key := range o.Order {
+		if val, ok := fields[key]; ok && val != nil && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	var rest []string
+	for key, val := range fields {
+		// We don't want keyless or value-less items.
+		if key == "" || val == nil || seen[key] {
+			continue
+		}
+
+		rest = append(rest, key)
+	}
+
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// levelOf returns the level stored in the giving entry.
+func levelOf(e metrics.Entry) string {
+	if cid, ok := e.Get(logTypeKey); ok {
+		if sid, ok := cid.(string); ok {
+			return sid
+		}
+	}
+
+	return UNKOWN
+}
+
+// Colored returns true/false if the giving writer is a terminal which should
+// receive colored output.
+func Colored(w io.Writer) bool {
+	if color.NoColor {
+		return false
+	}
+
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+//==============================================================================
+
+// Logfmt defines an Encoder which writes entries as logfmt lines.
+type Logfmt struct {
+	Options
+}
+
+// Encode writes the giving entry as a single logfmt line.
+func (l Logfmt) Encode(w io.Writer, e metrics.Entry, colored bool) error {
+	var bu bytes.Buffer
+
+	if stamp, ok := l.timestamp(time.Now(), time.RFC3339); ok {
+		writeLogfmtPair(&bu, l.key(TimeName), stamp)
+	}
+
+	writeLogfmtPair(&bu, l.key(LevelName), strings.ToLower(levelOf(e)))
+
+	if e.Message != "" {
+		writeLogfmtPair(&bu, l.key(MessageName), e.Message)
+	}
+
+	fields := entryFields(e)
+	delete(fields, logTypeKey)
+
+	for _, key := range l.keys(fields) {
+		writeLogfmtPair(&bu, l.key(key), metrics.PrintValue(fields[key]))
+	}
+
+	bu.WriteByte('\n')
+
+	_, err := bu.WriteTo(w)
+	return err
+}
+
+// writeLogfmtPair writes the key and value, quoting the value when required.
+func writeLogfmtPair(bu *bytes.Buffer, key string, value string) {
+	if bu.Len() > 0 {
+		bu.WriteByte(' ')
+	}
+
+	bu.WriteString(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, key))
+
+	bu.WriteByte('=')
+
+	if needsQuote(value) {
+		data, _ := json.Marshal(value)
+		bu.Write(data)
+		return
+	}
+
+	bu.WriteString(value)
+}
+
+// needsQuote returns true/false if the logfmt value must be quoted.
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//==============================================================================
+
+// JSON defines an Encoder which writes entries as json lines.
+type JSON struct {
+	Options
+}
+
+// Encode writes the giving entry as a single json object followed by a
+// newline, keeping the configured key order.
+func (j JSON) Encode(w io.Writer, e metrics.Entry, colored bool) error {
+	var bu bytes.Buffer
+
+	bu.WriteByte('{')
+
+	if stamp, ok := j.timestamp(time.Now(), time.RFC3339); ok {
+		if err := writeJSONPair(&bu, j.key(TimeName), stamp); err != nil {
+			return err
+		}
+	}
+
+	if err := writeJSONPair(&bu, j.key(LevelName), strings.ToLower(levelOf(e))); err != nil {
+		return err
+	}
+
+	if e.Message != "" {
+		if err := writeJSONPair(&bu, j.key(MessageName), e.Message); err != nil {
+			return err
+		}
+	}
+
+	fields := entryFields(e)
+	delete(fields, logTypeKey)
+
+	for _, key := range j.keys(fields) {
+		if err := writeJSONPair(&bu, j.key(key), fields[key]); err != nil {
+			return err
+		}
+	}
+
+	bu.WriteString("}\n")
+
+	_, err := bu.WriteTo(w)
+	return err
+}
+
+// writeJSONPair writes a json key-value pair into the object being built.
+func writeJSONPair(bu *bytes.Buffer, key string, value interface{}) error {
+	keyData, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	valData, err := json.Marshal(value)
+	if err != nil {
+		valData, _ = json.Marshal(metrics.PrintValue(value))
+	}
+
+	if bu.Len() > 1 {
+		bu.WriteByte(',')
+	}
+
+	bu.Write(keyData)
+	bu.WriteByte(':')
+	bu.Write(valData)
+
+	return nil
+}
+
+//==============================================================================
+
+// Console defines an Encoder which writes entries in a human readable format,
+// coloring the level and keys when the writer supports colors. Without
+// Options it writes the same lines as the Stdout sentry always has, where
+// fields are only written for known levels.
+type Console struct {
+	Options
+}
+
+// Encode writes the giving entry as a single human readable line.
+func (c Console) Encode(w io.Writer, e metrics.Entry, colored bool) error {
+	var bu bytes.Buffer
+
+	paint := func(col *color.Color, value string) {
+		if colored {
+			col.Fprint(&bu, value)
+			return
+		}
+
+		bu.WriteString(value)
+	}
+
+	id := levelOf(e)
+
+	var levelColor *color.Color
+
+	switch id {
+	case INFO:
+		levelColor = blue
+	case DEBUG:
+		levelColor = cyan
+	case ERROR:
+		levelColor = red
+	case NOTICE:
+		levelColor = white
+	default:
+		id, levelColor = UNKOWN, white
+	}
+
+	if stamp, ok := c.timestamp(time.Now(), ""); ok {
+		paint(black, stamp)
+		bu.WriteByte(' ')
+	}
+
+	paint(levelColor, id)
+	paint(black, "[opening]")
+	bu.Write([]byte(":"))
+
+	if e.Message != "" {
+		bu.Write([]byte("\t\t"))
+		bu.Write([]byte(e.Message))
+	}
+
+	bu.Write([]byte("\t\t"))
+
+	fields := entryFields(e)
+	if id == UNKOWN {
+		fields = nil
+	}
+
+	for _, key := range c.keys(fields) {
+		paint(levelColor, c.key(key))
+		paint(black, "=")
+		paint(black, metrics.PrintValue(fields[key]))
+		bu.Write([]byte(" "))
+	}
+
+	bu.Write([]byte("\n"))
+
+	_, err := bu.WriteTo(w)
+	return err
+}
+
+// entryFields returns the fields of the entry, handling entries without any
+// fields.
+func entryFields(e metrics.Entry) metrics.Fields {
+	if e.Pair == nil {
+		return metrics.Fields{}
+	}
+
+	return e.Fields()
+}
@@ -0,0 +1,111 @@
+package stdout_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/stdout"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+// TestLogfmt validates the ordering, renaming and quoting of the Logfmt
+// encoder.
+func TestLogfmt(t *testing.T) {
+	var bu bytes.Buffer
+
+	writer := stdout.Writer{
+		Out: &bu,
+		Encoder: stdout.Logfmt{Options: stdout.Options{
+			TimeFormat: "-",
+			Order:      []string{"user"},
+			Rename:     map[string]string{stdout.MessageName: "message"},
+		}},
+	}
+
+	entry := stdout.Info("user %s logged in", "bob").With("addr", "10.0.0.1").With("user", "bob smith")
+	if err := writer.Emit(entry); err != nil {
+		t.Fatalf("\t%s\tShould have encoded entry: %s", failedMark, err)
+	}
+
+	expected := `level=info message="user bob logged in" user="bob smith" addr=10.0.0.1` + "\n"
+	if bu.String() != expected {
+		t.Fatalf("\t%s\tShould have encoded logfmt line: %q", failedMark, bu.String())
+	}
+	t.Logf("\t%s\tShould have encoded logfmt line", succeedMark)
+}
+
+// TestJSON validates the ordering of the JSON encoder.
+func TestJSON(t *testing.T) {
+	var bu bytes.Buffer
+
+	writer := stdout.Writer{
+		Out:     &bu,
+		Encoder: stdout.JSON{Options: stdout.Options{TimeFormat: "-"}},
+	}
+
+	entry := stdout.Error("failed").With("count", 2).With("addr", "10.0.0.1")
+	if err := writer.Emit(entry); err != nil {
+		t.Fatalf("\t%s\tShould have encoded entry: %s", failedMark, err)
+	}
+
+	expected := `{"level":"error","msg":"failed","addr":"10.0.0.1","count":2}` + "\n"
+	if bu.String() != expected {
+		t.Fatalf("\t%s\tShould have encoded json line: %q", failedMark, bu.String())
+	}
+	t.Logf("\t%s\tShould have encoded json line", succeedMark)
+}
+
+// TestConsoleNoColor validates that the Console encoder writes no color codes
+// into writers which are not terminals.
+func TestConsoleNoColor(t *testing.T) {
+	var bu bytes.Buffer
+
+	writer := stdout.Writer{
+		Out:     &bu,
+		Encoder: stdout.Console{Options: stdout.Options{TimeFormat: "-"}},
+	}
+
+	if err := writer.Emit(stdout.Debug("loading").With("file", "a.go")); err != nil {
+		t.Fatalf("\t%s\tShould have encoded entry: %s", failedMark, err)
+	}
+
+	expected := "DEBUG[opening]:\t\tloading\t\tLogKEY=DEBUG file=a.go \n"
+	if bu.String() != expected {
+		t.Fatalf("\t%s\tShould have encoded plain console line: %q", failedMark, bu.String())
+	}
+	t.Logf("\t%s\tShould have encoded plain console line", succeedMark)
+}
+
+// TestConsoleDefault validates that the Console encoder without options writes
+// no timestamp, keeping the output of the Stdout sentry unchanged.
+func TestConsoleDefault(t *testing.T) {
+	var bu bytes.Buffer
+
+	writer := stdout.Writer{Out: &bu, NoColor: true}
+
+	writer.Emit(stdout.Info("started"))
+	writer.Emit(metrics.With("id", 1).WithMessage("other"))
+
+	expected := "INFO[opening]:\t\tstarted\t\tLogKEY=INFO \n" + "Unknown[opening]:\t\tother\t\t\n"
+	if bu.String() != expected {
+		t.Fatalf("\t%s\tShould have encoded console lines without timestamp: %q", failedMark, bu.String())
+	}
+	t.Logf("\t%s\tShould have encoded console lines without timestamp", succeedMark)
+
+	bu.Reset()
+	writer.Encoder = stdout.Console{Options: stdout.Options{TimeFormat: "2006"}}
+	writer.Emit(stdout.Info("started"))
+
+	if !strings.HasPrefix(bu.String(), time.Now().Format("2006")+" INFO") {
+		t.Fatalf("\t%s\tShould have written timestamp when set: %q", failedMark, bu.String())
+	}
+	t.Logf("\t%s\tShould have written timestamp when set", succeedMark)
+}
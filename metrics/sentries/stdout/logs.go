@@ -1,13 +1,10 @@
 package stdout
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 
 	"github.com/fatih/color"
 	"github.com/influx6/faux/metrics"
@@ -24,7 +21,7 @@ var (
 
 // sets of const used in package.
 const (
-	logTypeKey = "LogKEY"
+	logTypeKey = metrics.LevelKey
 	INFO       = "INFO"
 	DEBUG      = "DEBUG"
 	ERROR      = "ERROR"
@@ -79,177 +76,53 @@ func Debug(message string, m ...interface{}) metrics.Entry {
 
 //==============================================================================
 
-// Stdout emits all entries into the systems stdout.
-type Stdout struct{}
-
-// Emit implements the metrics.metrics interface and does nothing with the
-// provided entry.
-func (Stdout) Emit(e metrics.Entry) error {
-	var bu bytes.Buffer
-
-	var id string
+// Writer emits all entries into the provided io.Writer using the giving
+// Encoder, which defaults to the Console encoder.
+type Writer struct {
+	Out     io.Writer
+	Encoder Encoder
 
-	if cid, ok := e.Get(logTypeKey); ok {
-		if sid, ok := cid.(string); ok {
-			id = sid
-		}
-	}
-
-	switch id {
-	case INFO:
-		blue.Fprint(&bu, INFO)
-		break
-	case DEBUG:
-		cyan.Fprint(&bu, DEBUG)
-		break
-	case ERROR:
-		red.Fprint(&bu, ERROR)
-		break
-	case NOTICE:
-		white.Fprint(&bu, NOTICE)
-		break
-	default:
-		white.Fprint(&bu, UNKOWN)
-		break
-	}
-
-	black.Fprint(&bu, "[opening]")
-	bu.Write([]byte(":"))
+	// NoColor disables colors even when Out is a terminal.
+	NoColor bool
+}
 
-	if e.Message != "" {
-		bu.Write([]byte("\t\t"))
-		bu.Write([]byte(e.Message))
+// Emit implements the metrics.metrics interface and writes the provided entry
+// into the writer.
+func (w Writer) Emit(e metrics.Entry) error {
+	encoder := w.Encoder
+	if encoder == nil {
+		encoder = Console{}
 	}
 
-	printEntryParams(&bu, e)
-	bu.Write([]byte("\n"))
-
-	bu.WriteTo(os.Stdout)
-
-	return nil
+	return encoder.Encode(w.Out, e, !w.NoColor && Colored(w.Out))
 }
 
 //==============================================================================
 
-// Stderr emits all entries into the systems stderr.
-type Stderr struct{}
-
-// Emit implements the metrics.metrics interface and does nothing with the
-// provided entry.
-func (Stderr) Emit(e metrics.Entry) error {
-	var bu bytes.Buffer
-
-	var id string
-
-	if cid, ok := e.Get(logTypeKey); ok {
-		if sid, ok := cid.(string); ok {
-			id = sid
-		}
-	}
-
-	switch id {
-	case ERROR:
-		red.Fprint(&bu, "ERROR")
-		break
-	default:
-		return errors.New("Only Error ID allowed")
-	}
-
-	black.Fprint(&bu, "[opening]")
-	bu.Write([]byte(":"))
-
-	if e.Message != "" {
-		bu.Write([]byte("\t\t"))
-		bu.Write([]byte(e.Message))
-	}
-
-	printEntryParams(&bu, e)
-	bu.Write([]byte("\n"))
-
-	bu.WriteTo(os.Stdout)
-	return nil
+// Stdout emits all entries into the systems stdout.
+type Stdout struct {
+	Encoder Encoder
 }
 
-func printEntryParams(bu io.Writer, e metrics.Entry) {
-	bu.Write([]byte("\t\t"))
-
-	fields := e.Fields()
-
-	var id string
-
-	if cid, ok := e.Get(logTypeKey); ok {
-		if sid, ok := cid.(string); ok {
-			id = sid
-		}
-	}
-
-	for key, val := range fields {
-
-		// We don't want keyless or value-less items.
-		if key == "" || val == nil {
-			continue
-		}
-
-		switch id {
-		case INFO:
-			blue.Fprint(bu, key)
-			black.Fprint(bu, "=")
-			black.Fprint(bu, printValue(val))
-			bu.Write([]byte(" "))
-			break
-		case DEBUG:
-			cyan.Fprint(bu, key)
-			black.Fprint(bu, "=")
-			black.Fprint(bu, printValue(val))
-			bu.Write([]byte(" "))
-			break
-		case ERROR:
-			red.Fprint(bu, key)
-			black.Fprint(bu, "=")
-			black.Fprint(bu, printValue(val))
-			bu.Write([]byte(" "))
-			break
-		case NOTICE:
-			white.Fprint(bu, key)
-			black.Fprint(bu, "=")
-			black.Fprint(bu, printValue(val))
-			bu.Write([]byte(" "))
-			break
-		}
-	}
-
+// Emit implements the metrics.metrics interface and writes the provided
+// entry into the systems stdout.
+func (s Stdout) Emit(e metrics.Entry) error {
+	return Writer{Out: os.Stdout, Encoder: s.Encoder}.Emit(e)
 }
 
-type stringer interface {
-	String() string
+//==============================================================================
+
+// Stderr emits all error entries into the systems stderr.
+type Stderr struct {
+	Encoder Encoder
 }
 
-func printValue(val interface{}) string {
-	switch bo := val.(type) {
-	case string:
-		return bo
-	case stringer:
-		return bo.String()
-	case int:
-		return strconv.Itoa(bo)
-	case int64:
-		return strconv.Itoa(int(bo))
-	case rune:
-		return strconv.QuoteRune(bo)
-	case bool:
-		return strconv.FormatBool(bo)
-	case byte:
-		return strconv.QuoteRune(rune(bo))
-	case float64:
-		return strconv.FormatFloat(bo, 'f', 4, 64)
-	case float32:
-		return strconv.FormatFloat(float64(bo), 'f', 4, 64)
-	default:
-		data, err := json.Marshal(val)
-		if err != nil {
-			return "-"
-		}
-
-		return string(data)
+// Emit implements the metrics.metrics interface and writes the provided
+// entry into the systems stderr, it only allows entries with the ERROR id.
+func (s Stderr) Emit(e metrics.Entry) error {
+	if levelOf(e) != ERROR {
+		return errors.New("Only Error ID allowed")
 	}
+
+	return Writer{Out: os.Stderr, Encoder: s.Encoder}.Emit(e)
 }
@@ -0,0 +1,310 @@
+// Package syslog provides a metrics.Sentry which delivers entries to a syslog
+// server using either the RFC 5424 or RFC 3164 format over udp, tcp or unix
+// sockets.
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+)
+
+// Format defines the message format used when writing to syslog.
+type Format int
+
+// contains the supported syslog formats.
+const (
+	RFC5424 Format = iota
+	RFC3164
+)
+
+// Priority defines a syslog severity value.
+type Priority int
+
+// contains the syslog severities.
+const (
+	Emergency Priority = iota
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Info
+	Debug
+)
+
+// contains the syslog facilities commonly used by applications.
+const (
+	User   = 1
+	Daemon = 3
+	Local0 = 16
+	Local1 = 17
+	Local2 = 18
+	Local3 = 19
+	Local4 = 20
+	Local5 = 21
+	Local6 = 22
+	Local7 = 23
+)
+
+// SeverityFor returns the syslog severity for the giving SentryJSON based on
+// the level stored under metrics.LevelKey, defaulting to Info.
+func SeverityFor(sjn metrics.SentryJSON) Priority {
+	level, _ := sjn.Fields[metrics.LevelKey].(string)
+
+	switch strings.ToUpper(level) {
+	case "ERROR":
+		return Error
+	case "NOTICE":
+		return Notice
+	case "DEBUG":
+		return Debug
+	case "WARNING", "WARN":
+		return Warning
+	default:
+		return Info
+	}
+}
+
+//==============================================================================
+
+// Config defines the configuration for a Syslog sentry.
+type Config struct {
+	// Network sets the network used to dial, one of "udp", "tcp", "unix" and
+	// "unixgram".
+	Network string
+
+	// Addr sets the address of the syslog server or path of the unix socket.
+	Addr string
+
+	// Format sets the message format, RFC5424 by default.
+	Format Format
+
+	// Facility sets the syslog facility, User by default.
+	Facility int
+
+	// Hostname sets the hostname written into messages, os.Hostname by default.
+	Hostname string
+
+	// AppName sets the application name or tag written into messages, the
+	// executable name by default.
+	AppName string
+}
+
+// Syslog defines a metrics.Sentry which writes entries to a syslog server.
+type Syslog struct {
+	config Config
+	pid    int
+
+	ml   sync.Mutex
+	conn net.Conn
+}
+
+// New returns a new Syslog sentry with the provided Config. The connection is
+// established on the first emitted entry.
+func New(config Config) *Syslog {
+	if config.Facility == 0 {
+		config.Facility = User
+	}
+
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+
+	return &Syslog{
+		config: config,
+		pid:    os.Getpid(),
+	}
+}
+
+// Emit formats and writes the giving SentryJSON to the syslog server,
+// reconnecting once if the write fails.
+func (s *Syslog) Emit(sjn metrics.SentryJSON) error {
+	message := s.frame(s.Format(sjn))
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if err := s.write(message); err != nil {
+		s.closeConn()
+		return s.write(message)
+	}
+
+	return nil
+}
+
+// Close closes the connection to the syslog server.
+func (s *Syslog) Close() error {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	return s.closeConn()
+}
+
+// Format returns the giving SentryJSON formatted in the configured syslog
+// format without transport framing.
+func (s *Syslog) Format(sjn metrics.SentryJSON) []byte {
+	priority := s.config.Facility*8 + int(SeverityFor(sjn))
+
+	var bu bytes.Buffer
+
+	switch s.config.Format {
+	case RFC3164:
+		fmt.Fprintf(&bu, "<%d>%s %s %s[%d]: %s", priority, sjn.Time.Format(time.Stamp),
+			s.config.Hostname, s.config.AppName, s.pid, sjn.Message)
+
+		for _, key := range sortedKeys(sjn.Fields) {
+			fmt.Fprintf(&bu, " %s=%s", key, metrics.PrintValue(sjn.Fields[key]))
+		}
+	default:
+		fmt.Fprintf(&bu, "<%d>1 %s %s %s %d - ", priority, sjn.Time.Format(time.RFC3339Nano),
+			header(s.config.Hostname, maxHostname), header(s.config.AppName, maxAppName), s.pid)
+
+		writeStructuredData(&bu, sjn.Fields)
+
+		bu.WriteString(" ")
+		bu.WriteString(sjn.Message)
+	}
+
+	return bu.Bytes()
+}
+
+// frame applies the transport framing required by stream connections, using
+// octet counting for RFC 5424 and newline termination for RFC 3164.
+func (s *Syslog) frame(message []byte) []byte {
+	switch s.config.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		if s.config.Format == RFC3164 {
+			return append(message, '\n')
+		}
+
+		return append([]byte(strconv.Itoa(len(message))+" "), message...)
+	default:
+		return message
+	}
+}
+
+// write dials the server if needed and writes the giving message.
+func (s *Syslog) write(message []byte) error {
+	if s.conn == nil {
+		conn, err := net.Dial(s.config.Network, s.config.Addr)
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+	}
+
+	_, err := s.conn.Write(message)
+	return err
+}
+
+// closeConn closes the current connection if any.
+func (s *Syslog) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+//==============================================================================
+
+// contains the max lengths of the RFC 5424 header fields.
+const (
+	maxHostname = 255
+	maxAppName  = 48
+)
+
+// header returns the giving value as a valid RFC 5424 header field of at most
+// max characters.
+func header(value string, max int) string {
+	if value == "" {
+		return "-"
+	}
+
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, value)
+
+	if len(value) > max {
+		return value[:max]
+	}
+
+	return value
+}
+
+// writeStructuredData writes the fields as a single RFC 5424 structured data
+// element.
+func writeStructuredData(bu *bytes.Buffer, fields metrics.Fields) {
+	keys := sortedKeys(fields)
+	if len(keys) == 0 {
+		bu.WriteString("-")
+		return
+	}
+
+	bu.WriteString("[fields@32473")
+
+	for _, key := range keys {
+		fmt.Fprintf(bu, " %s=\"%s\"", paramName(key), paramValue(metrics.PrintValue(fields[key])))
+	}
+
+	bu.WriteString("]")
+}
+
+// paramName returns the giving key as a valid structured data param name.
+func paramName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' || r == ' ' {
+			return '_'
+		}
+
+		return r
+	}, key)
+
+	if len(name) > 32 {
+		return name[:32]
+	}
+
+	return name
+}
+
+// paramValue escapes the characters required by RFC 5424 param values.
+func paramValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// sortedKeys returns the keys of the fields in sorted order.
+func sortedKeys(fields metrics.Fields) []string {
+	keys := make([]string, 0, len(fields))
+
+	for key, value := range fields {
+		if key == "" || value == nil {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
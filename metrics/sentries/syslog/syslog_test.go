@@ -0,0 +1,126 @@
+package syslog_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/sentries/syslog"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+var entry = metrics.SentryJSON{
+	Time:    time.Date(2017, 3, 4, 10, 20, 30, 0, time.UTC),
+	Message: "user logged in",
+	Fields:  metrics.Fields{"LogKEY": "ERROR", "user": `bob "the" builder`},
+}
+
+// TestSyslogUDP validates the delivery of RFC 5424 messages over udp.
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created udp listener: %s", failedMark, err)
+	}
+	defer conn.Close()
+
+	sl := syslog.New(syslog.Config{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Hostname: "box",
+		AppName:  "app",
+	})
+	defer sl.Close()
+
+	if err := sl.Emit(entry); err != nil {
+		t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have emitted entry", succeedMark)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	data := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(data)
+	if err != nil {
+		t.Fatalf("\t%s\tShould have received message: %s", failedMark, err)
+	}
+
+	message := string(data[:n])
+	if !strings.HasPrefix(message, "<11>1 2017-03-04T10:20:30Z box app ") {
+		t.Fatalf("\t%s\tShould have received RFC 5424 header: %q", failedMark, message)
+	}
+	t.Logf("\t%s\tShould have received RFC 5424 header", succeedMark)
+
+	if !strings.HasSuffix(message, `[fields@32473 LogKEY="ERROR" user="bob \"the\" builder"] user logged in`) {
+		t.Fatalf("\t%s\tShould have received escaped structured data: %q", failedMark, message)
+	}
+	t.Logf("\t%s\tShould have received escaped structured data", succeedMark)
+}
+
+// TestSyslogTCP validates the delivery of RFC 3164 messages over tcp.
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("\t%s\tShould have created tcp listener: %s", failedMark, err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	sl := syslog.New(syslog.Config{
+		Network:  "tcp",
+		Addr:     listener.Addr().String(),
+		Format:   syslog.RFC3164,
+		Facility: syslog.Local0,
+		Hostname: "box",
+		AppName:  "app",
+	})
+	defer sl.Close()
+
+	if err := sl.Emit(entry); err != nil {
+		t.Fatalf("\t%s\tShould have emitted entry: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have emitted entry", succeedMark)
+
+	select {
+	case line := <-lines:
+		if !strings.HasPrefix(line, "<131>Mar  4 10:20:30 box app[") || !strings.Contains(line, "]: user logged in") {
+			t.Fatalf("\t%s\tShould have received RFC 3164 message: %q", failedMark, line)
+		}
+		t.Logf("\t%s\tShould have received RFC 3164 message", succeedMark)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("\t%s\tShould have received RFC 3164 message", failedMark)
+	}
+}
+
+// TestSyslogHeaderLimits validates that RFC 5424 header fields are cut to
+// their max lengths.
+func TestSyslogHeaderLimits(t *testing.T) {
+	sl := syslog.New(syslog.Config{
+		Hostname: strings.Repeat("h", 300),
+		AppName:  strings.Repeat("a", 60),
+	})
+
+	fields := strings.Fields(string(sl.Format(entry)))
+	if len(fields) < 4 || len(fields[2]) != 255 || len(fields[3]) != 48 {
+		t.Fatalf("\t%s\tShould have cut hostname and app name: %+q", failedMark, fields)
+	}
+	t.Logf("\t%s\tShould have cut hostname and app name", succeedMark)
+}
@@ -0,0 +1,238 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/influx6/faux/metrics"
+)
+
+// contains the OTLP status and kind codes used by exported spans.
+const (
+	otlpStatusUnset  = 0
+	otlpStatusError  = 2
+	otlpKindInternal = 1
+)
+
+// OTLPFile defines an Exporter which writes spans as OTLP/JSON
+// ExportTraceServiceRequest objects, one per line, into a file.
+type OTLPFile struct {
+	service string
+	path    string
+
+	wl   sync.Mutex
+	file *os.File
+}
+
+// NewOTLPFile returns a new OTLPFile which appends to the file at the giving
+// path and tags all spans with the giving service name.
+func NewOTLPFile(path string, service string) *OTLPFile {
+	return &OTLPFile{
+		path:    path,
+		service: service,
+	}
+}
+
+// Export writes the giving span into the file.
+func (o *OTLPFile) Export(data SpanData) error {
+	o.wl.Lock()
+	defer o.wl.Unlock()
+
+	if o.file == nil {
+		fm, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+
+		o.file = fm
+	}
+
+	return WriteOTLP(o.file, o.service, data)
+}
+
+// Close closes the underline file.
+func (o *OTLPFile) Close() error {
+	o.wl.Lock()
+	defer o.wl.Unlock()
+
+	if o.file == nil {
+		return nil
+	}
+
+	err := o.file.Close()
+	o.file = nil
+	return err
+}
+
+// WriteOTLP writes the giving spans as a single OTLP/JSON line into the
+// writer.
+func WriteOTLP(w io.Writer, service string, spans ...SpanData) error {
+	request := otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: otlpAttributes(metrics.Fields{"service.name": service}),
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "github.com/influx6/faux/metrics/tracing"},
+						Spans: make([]otlpSpan, 0, len(spans)),
+					},
+				},
+			},
+		},
+	}
+
+	scope := &request.ResourceSpans[0].ScopeSpans[0]
+
+	for _, data := range spans {
+		span := otlpSpan{
+			TraceID:           data.TraceID.String(),
+			SpanID:            data.SpanID.String(),
+			Name:              data.Name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(data.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(data.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(data.Attributes),
+			Status:            otlpStatus{Code: otlpStatusUnset},
+		}
+
+		if !data.ParentID.IsZero() {
+			span.ParentSpanID = data.ParentID.String()
+		}
+
+		if data.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: data.Error}
+		}
+
+		for _, event := range data.Events {
+			span.Events = append(span.Events, otlpEvent{
+				Name:         event.Name,
+				TimeUnixNano: strconv.FormatInt(event.Time.UnixNano(), 10),
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//==============================================================================
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	Name         string         `json:"name"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// otlpAttributes returns the giving fields as sorted OTLP attributes.
+func otlpAttributes(fields metrics.Fields) []otlpKeyValue {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, otlpKeyValue{Key: key, Value: otlpValue(fields[key])})
+	}
+
+	return attrs
+}
+
+// otlpValue returns the OTLP AnyValue representation of the giving value.
+func otlpValue(value interface{}) map[string]interface{} {
+	switch bo := value.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": bo}
+	case bool:
+		return map[string]interface{}{"boolValue": bo}
+	case int:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(bo), 10)}
+	case int32:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(bo), 10)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(bo, 10)}
+	case uint:
+		return map[string]interface{}{"intValue": strconv.FormatUint(uint64(bo), 10)}
+	case uint32:
+		return map[string]interface{}{"intValue": strconv.FormatUint(uint64(bo), 10)}
+	case uint64:
+		return map[string]interface{}{"intValue": strconv.FormatUint(bo, 10)}
+	case float32:
+		return map[string]interface{}{"doubleValue": float64(bo)}
+	case float64:
+		return map[string]interface{}{"doubleValue": bo}
+	case fmt.Stringer:
+		return map[string]interface{}{"stringValue": bo.String()}
+	case error:
+		return map[string]interface{}{"stringValue": bo.Error()}
+	default:
+		data, err := json.Marshal(bo)
+		if err != nil {
+			return map[string]interface{}{"stringValue": fmt.Sprintf("%+v", bo)}
+		}
+
+		return map[string]interface{}{"stringValue": string(data)}
+	}
+}
@@ -0,0 +1,340 @@
+// Package tracing provides spans with trace and span ids on top of the
+// metrics package, which allows following a call across workers,
+// subscriptions and processes through a faux context.Context.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/metrics"
+)
+
+// contains the keys used for the fields of span entries.
+const (
+	TraceIDKey  = "trace_id"
+	SpanIDKey   = "span_id"
+	ParentIDKey = "parent_id"
+	SpanKey     = "span"
+)
+
+//==============================================================================
+
+// TraceID defines the 16 byte id shared by all spans of a trace.
+type TraceID [16]byte
+
+// String returns the hex representation of the id.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsZero returns true/false if the id is unset.
+func (t TraceID) IsZero() bool {
+	return t == TraceID{}
+}
+
+// SpanID defines the 8 byte id of a single span.
+type SpanID [8]byte
+
+// String returns the hex representation of the id.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsZero returns true/false if the id is unset.
+func (s SpanID) IsZero() bool {
+	return s == SpanID{}
+}
+
+// newTraceID returns a new random TraceID.
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+// newSpanID returns a new random SpanID.
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
+
+//==============================================================================
+
+// Sampler defines an interface which decides if a new trace is recorded.
+type Sampler interface {
+	Sample(id TraceID, name string) bool
+}
+
+// SamplerFunc defines a function type which implements the Sampler interface.
+type SamplerFunc func(id TraceID, name string) bool
+
+// Sample calls the underline function.
+func (fn SamplerFunc) Sample(id TraceID, name string) bool {
+	return fn(id, name)
+}
+
+// AlwaysSample defines a Sampler which records all traces.
+var AlwaysSample = SamplerFunc(func(TraceID, string) bool { return true })
+
+// NeverSample defines a Sampler which records no traces.
+var NeverSample = SamplerFunc(func(TraceID, string) bool { return false })
+
+// RatioSample returns a Sampler which records the giving ratio of traces,
+// deciding by the trace id so all processes agree on a trace.
+func RatioSample(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample
+	}
+
+	if ratio <= 0 {
+		return NeverSample
+	}
+
+	bound := uint64(ratio * (1 << 63))
+
+	return SamplerFunc(func(id TraceID, _ string) bool {
+		return binary.BigEndian.Uint64(id[8:])>>1 < bound
+	})
+}
+
+//==============================================================================
+
+// Exporter defines an interface which receives finished and sampled spans.
+type Exporter interface {
+	Export(SpanData) error
+}
+
+// EntryExporter defines an Exporter which delivers finished spans as
+// metrics.Entry values into a metrics.Metrics.
+type EntryExporter struct {
+	Metrics metrics.Metrics
+}
+
+// Export delivers the span as an Entry.
+func (e EntryExporter) Export(data SpanData) error {
+	return e.Metrics.Emit(data.Entry())
+}
+
+//==============================================================================
+
+// Event defines a timestamped event recorded on a span.
+type Event struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes metrics.Fields `json:"attributes,omitempty"`
+}
+
+// SpanData defines the recorded data of a span.
+type SpanData struct {
+	TraceID    TraceID        `json:"-"`
+	SpanID     SpanID         `json:"-"`
+	ParentID   SpanID         `json:"-"`
+	Name       string         `json:"name"`
+	StartTime  time.Time      `json:"start_time"`
+	EndTime    time.Time      `json:"end_time"`
+	Attributes metrics.Fields `json:"attributes,omitempty"`
+	Events     []Event        `json:"events,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// Entry returns the span data as a metrics.Entry.
+func (s SpanData) Entry() metrics.Entry {
+	entry := metrics.With(TraceIDKey, s.TraceID.String()).
+		With(SpanIDKey, s.SpanID.String()).
+		With(SpanKey, s).
+		WithMessage("span %s finished in %s", s.Name, s.EndTime.Sub(s.StartTime))
+
+	if !s.ParentID.IsZero() {
+		entry = entry.With(ParentIDKey, s.ParentID.String())
+	}
+
+	return entry
+}
+
+//==============================================================================
+
+// Tracer defines a structure which starts spans and exports them once they
+// end.
+type Tracer struct {
+	sampler   Sampler
+	exporters []Exporter
+	onError   func(error)
+}
+
+// New returns a new Tracer using the giving sampler, which defaults to
+// AlwaysSample, and exporters.
+func New(sampler Sampler, exporters ...Exporter) *Tracer {
+	if sampler == nil {
+		sampler = AlwaysSample
+	}
+
+	return &Tracer{
+		sampler:   sampler,
+		exporters: exporters,
+	}
+}
+
+// OnError sets the function called when an exporter fails.
+func (t *Tracer) OnError(fn func(error)) {
+	t.onError = fn
+}
+
+// spanKey defines the key used to store spans in a context.
+type spanKey struct{}
+
+// FromContext returns the active span stored in the giving context.
+func FromContext(ctx context.Context) (*Span, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	val, ok := ctx.Get(spanKey{})
+	if !ok {
+		return nil, false
+	}
+
+	span, ok := val.(*Span)
+	return span, ok
+}
+
+// Start returns a new span which is a child of the span stored in the giving
+// context, if any, and a new context which carries the new span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{tracer: t}
+	span.data.Name = name
+	span.data.StartTime = time.Now()
+	span.data.SpanID = newSpanID()
+
+	if parent, ok := FromContext(ctx); ok {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+		span.sampled = parent.sampled
+	} else {
+		span.data.TraceID = newTraceID()
+		span.sampled = t.sampler.Sample(span.data.TraceID, name)
+	}
+
+	if ctx == nil {
+		ctx = context.New()
+	}
+
+	return ctx.WithValue(spanKey{}, span), span
+}
+
+// export delivers the giving span data to all exporters.
+func (t *Tracer) export(data SpanData) {
+	for _, exporter := range t.exporters {
+		if err := exporter.Export(data); err != nil && t.onError != nil {
+			t.onError(err)
+		}
+	}
+}
+
+//==============================================================================
+
+// Span defines a single traced operation within a trace.
+type Span struct {
+	tracer  *Tracer
+	sampled bool
+
+	ml    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// TraceID returns the id of the trace the span belongs to.
+func (s *Span) TraceID() TraceID {
+	return s.data.TraceID
+}
+
+// SpanID returns the id of the span.
+func (s *Span) SpanID() SpanID {
+	return s.data.SpanID
+}
+
+// ParentID returns the id of the parent span, which is zero for root spans.
+func (s *Span) ParentID() SpanID {
+	return s.data.ParentID
+}
+
+// Sampled returns true/false if the span will be exported.
+func (s *Span) Sampled() bool {
+	return s.sampled
+}
+
+// SetAttribute sets the giving key and value on the span. Spans which have
+// ended are not changed.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(metrics.Fields)
+	}
+
+	s.data.Attributes[key] = value
+}
+
+// AddEvent records a named event with the giving attributes on the span.
+func (s *Span) AddEvent(name string, attrs metrics.Fields) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.data.Events = append(s.data.Events, Event{
+		Name:       name,
+		Time:       time.Now(),
+		Attributes: attrs,
+	})
+}
+
+// SetError records the giving error on the span.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.data.Error = err.Error()
+}
+
+// End finishes the span and exports it if sampled. Calling End more than
+// once has no effect.
+func (s *Span) End() SpanData {
+	s.ml.Lock()
+	if s.ended {
+		data := s.data
+		s.ml.Unlock()
+		return data
+	}
+
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.ml.Unlock()
+
+	if s.sampled && s.tracer != nil {
+		s.tracer.export(data)
+	}
+
+	return data
+}
@@ -0,0 +1,100 @@
+package tracing_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/metrics"
+	"github.com/influx6/faux/metrics/tracing"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "✓"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "✗"
+
+type recorder struct {
+	spans []tracing.SpanData
+}
+
+func (r *recorder) Export(data tracing.SpanData) error {
+	r.spans = append(r.spans, data)
+	return nil
+}
+
+// TestSpanPropagation validates that child spans started from a context
+// share the trace id of their parent.
+func TestSpanPropagation(t *testing.T) {
+	rc := new(recorder)
+	tracer := tracing.New(tracing.AlwaysSample, rc)
+
+	ctx, root := tracer.Start(context.New(), "request")
+	_, child := tracer.Start(ctx, "db.query")
+
+	child.SetAttribute("table", "users")
+	child.AddEvent("retry", metrics.Fields{"attempt": 2})
+	child.SetError(errors.New("timeout"))
+	child.End()
+	root.End()
+	root.End()
+
+	if child.TraceID() != root.TraceID() || child.ParentID() != root.SpanID() {
+		t.Fatalf("\t%s\tShould have propagated trace and parent ids", failedMark)
+	}
+	t.Logf("\t%s\tShould have propagated trace and parent ids", succeedMark)
+
+	if len(rc.spans) != 2 {
+		t.Fatalf("\t%s\tShould have exported each span once: %d", failedMark, len(rc.spans))
+	}
+	t.Logf("\t%s\tShould have exported each span once", succeedMark)
+
+	var bu bytes.Buffer
+	if err := tracing.WriteOTLP(&bu, "api", rc.spans...); err != nil {
+		t.Fatalf("\t%s\tShould have written OTLP json: %s", failedMark, err)
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Status       struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	if err := json.Unmarshal(bu.Bytes(), &request); err != nil {
+		t.Fatalf("\t%s\tShould have written valid OTLP json: %s", failedMark, err)
+	}
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if spans[0].TraceID != root.TraceID().String() || spans[0].ParentSpanID != root.SpanID().String() || spans[0].Status.Code != 2 {
+		t.Fatalf("\t%s\tShould have written child span as OTLP span: %+v", failedMark, spans[0])
+	}
+	t.Logf("\t%s\tShould have written child span as OTLP span", succeedMark)
+}
+
+// TestSampling validates that children follow the sampling decision of their
+// root span.
+func TestSampling(t *testing.T) {
+	rc := new(recorder)
+	tracer := tracing.New(tracing.NeverSample, rc)
+
+	ctx, root := tracer.Start(nil, "request")
+	_, child := tracer.Start(ctx, "db.query")
+	child.End()
+	root.End()
+
+	if root.Sampled() || child.Sampled() || len(rc.spans) != 0 {
+		t.Fatalf("\t%s\tShould have skipped unsampled spans", failedMark)
+	}
+	t.Logf("\t%s\tShould have skipped unsampled spans", succeedMark)
+}
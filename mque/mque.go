@@ -4,205 +4,752 @@
 package mque
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/influx6/faux/panics"
 	"github.com/influx6/faux/reflection"
 )
 
+// ErrStopPropagation can be returned by a callback to stop the argument from
+// reaching callbacks with a lower priority. It is not reported by RunE.
+var ErrStopPropagation = errors.New("mque: stop propagation")
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// dispatchValue defines the type used in place of nil arguments and as result
+// of the function types used as dispatch keys for many arguments, it can not
+// be used by callers so those keys never match a published type.
+type dispatchValue struct{}
+
+var dispatchType = reflect.TypeOf(dispatchValue{})
+
 //==============================================================================
 
-// End defines an interface which exposes a End function.
+// End defines an interface which exposes a End function and the stable ID of
+// a subscription.
 type End interface {
+	ID() int64
 	End()
 	AddEnd(func())
 }
 
-// New returns a new implementer of Qu.
+// Options defines the settings for a subscription added with Subscribe.
+type Options struct {
+	// Priority sets the order of the callback, where callbacks with a higher
+	// priority run first. Callbacks with equal priority run in the order they
+	// were added.
+	Priority int
+
+	// Once sets the callback to be ended after it has been called once.
+	Once bool
+}
+
+// Mode defines how a MQue executes the callbacks for published arguments.
+type Mode int
+
+// contains the execution modes of a MQue.
+const (
+	// Inline runs callbacks on the goroutine calling Run.
+	Inline Mode = iota
+
+	// Goroutine runs every callback in its own goroutine.
+	Goroutine
+
+	// Pool runs callbacks on a bounded set of worker goroutines.
+	Pool
+)
+
+// DefaultWorkers defines the number of workers used by the Pool mode when
+// none is set.
+const DefaultWorkers = 4
+
+// Config defines the settings for a MQue created with NewWithConfig.
+type Config struct {
+	// Mode sets how callbacks are executed, Inline by default.
+	Mode Mode
+
+	// Workers sets the number of worker goroutines used by the Pool mode.
+	Workers int
+
+	// QueueSize sets the number of callbacks which can wait for a worker in
+	// the Pool mode, after which Run blocks until a worker is free or the
+	// queue is closed.
+	QueueSize int
+
+	// OnError is called with every panic recovered from a callback as a
+	// *PanicError and, for the Goroutine and Pool modes, with the errors
+	// returned by callbacks. It may be called from many goroutines. When
+	// nil, panics of callbacks are not recovered.
+	OnError func(error)
+}
+
+// PanicError defines the error reported when a callback panics.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the panic value as an error message.
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("mque: callback panicked: %v", p.Value)
+}
+
+// Errors defines a list of errors returned by callbacks during a RunE call.
+type Errors []error
+
+// Error returns the messages of all errors joined by a newline.
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// New returns a new implementer of Qu which runs callbacks inline.
 func New() *MQue {
+	return NewWithConfig(Config{})
+}
+
+// NewWithConfig returns a new implementer of Qu using the giving config. A
+// MQue using the Pool mode should be closed with Close to stop its workers.
+func NewWithConfig(config Config) *MQue {
 	var any mqueSub
-	return &MQue{any: &any}
+
+	mq := &MQue{
+		any:    &any,
+		config: config,
+		cache:  make(map[reflect.Type][]mqueMatch),
+	}
+
+	if config.Mode == Pool {
+		if config.Workers <= 0 {
+			config.Workers = DefaultWorkers
+		}
+
+		mq.jobs = make(chan mqueCall, config.QueueSize)
+		mq.done = make(chan struct{})
+
+		for i := 0; i < config.Workers; i++ {
+			go mq.work()
+		}
+	}
+
+	return mq
 }
 
 //==============================================================================
 
-// MQue defines a callback queue, that accept only one argument functions.
+// MQue defines a callback queue, where callbacks are called with the published
+// arguments which can be used for their parameters.
 type MQue struct {
 	l      sync.RWMutex
+	seq    int64
 	muxers []*mqueSub
 	any    *mqueSub
+
+	cl    sync.RWMutex
+	cache map[reflect.Type][]mqueMatch
+
+	config  Config
+	wg      sync.WaitGroup
+	pl      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	senders sync.WaitGroup
+	jobs    chan mqueCall
 }
 
 // Flush ends the queue listeners.
 func (m *MQue) Flush() {
 	m.l.Lock()
 	m.muxers = nil
+	m.reset()
 	m.l.Unlock()
 }
 
-// Run applies the argument against the queues callbacks.
-func (m *MQue) Run(val interface{}) {
+// Run applies the arguments against the queues callbacks.
+func (m *MQue) Run(vals ...interface{}) {
+	m.RunE(vals...)
+}
+
+// RunE applies the arguments against the queues callbacks in order of their
+// priority and returns the errors returned by the callbacks as Errors. A
+// callback returning ErrStopPropagation stops the arguments from reaching the
+// remaining callbacks.
+//
+// For the Goroutine and Pool modes, callbacks are only started in order of
+// their priority, errors are delivered to Config.OnError and RunE always
+// returns nil.
+func (m *MQue) RunE(vals ...interface{}) error {
+	var errs Errors
+
+	for _, call := range m.calls(vals) {
+		if call.fn.once {
+			if !atomic.CompareAndSwapInt32(&call.fn.fired, 0, 1) {
+				continue
+			}
+
+			call.fn.sub.End()
+		}
+
+		if m.config.Mode != Inline {
+			m.dispatch(call)
+			continue
+		}
+
+		err := m.call(call)
+		if err == ErrStopPropagation {
+			break
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Wait blocks until all callbacks started by the Goroutine and Pool modes
+// have finished.
+func (m *MQue) Wait() {
+	m.wg.Wait()
+}
+
+// Close waits for all started callbacks and stops the workers of the Pool
+// mode. Callbacks of arguments published after Close, or waiting for a free
+// worker when Close is called, are run inline.
+func (m *MQue) Close() {
+	m.pl.Lock()
+	if m.closed {
+		m.pl.Unlock()
+		return
+	}
+
+	m.closed = true
+
+	if m.done != nil {
+		close(m.done)
+	}
+	m.pl.Unlock()
+
+	m.senders.Wait()
+	m.Wait()
+
+	if m.jobs != nil {
+		close(m.jobs)
+	}
+}
+
+// dispatch starts the giving callback based on the mode of the queue.
+func (m *MQue) dispatch(call mqueCall) {
+	m.pl.RLock()
+	if m.closed {
+		m.pl.RUnlock()
+		m.finish(call)
+		return
+	}
+
+	m.wg.Add(1)
+
+	if m.config.Mode != Pool {
+		m.pl.RUnlock()
+
+		go func() {
+			defer m.wg.Done()
+			m.finish(call)
+		}()
+
+		return
+	}
+
+	// The lock is not held while waiting for a worker, as a callback
+	// publishing into a full queue would otherwise block Close.
+	m.senders.Add(1)
+	m.pl.RUnlock()
+
+	defer m.senders.Done()
+
+	select {
+	case m.jobs <- call:
+	case <-m.done:
+		defer m.wg.Done()
+		m.finish(call)
+	}
+}
+
+// work runs the callbacks received by a worker of the Pool mode.
+func (m *MQue) work() {
+	for call := range m.jobs {
+		m.finish(call)
+		m.wg.Done()
+	}
+}
+
+// finish runs the giving callback and reports its error to the error hook.
+func (m *MQue) finish(call mqueCall) {
+	err := m.call(call)
+	if err == nil || err == ErrStopPropagation {
+		return
+	}
+
+	if _, ok := err.(*PanicError); ok {
+		return
+	}
+
+	m.report(err)
+}
+
+// call runs the giving callback, recovering any panic as a *PanicError which
+// is also delivered to the error hook. Panics are not recovered if no error
+// hook is set.
+func (m *MQue) call(call mqueCall) error {
+	if m.config.OnError == nil {
+		return call.fn.call(call.args)
+	}
+
+	var perr *PanicError
+
+	err := panics.RecoverHandler("mque", func() error {
+		return call.fn.call(call.args)
+	}, func(val interface{}) {
+		perr = &PanicError{Value: val, Stack: debug.Stack()}
+	})
+
+	if perr != nil {
+		m.report(perr)
+		return perr
+	}
+
+	return err
+}
+
+// report delivers the giving error to the error hook if set.
+func (m *MQue) report(err error) {
+	if m.config.OnError != nil {
+		m.config.OnError(err)
+	}
+}
+
+// calls returns the callbacks matching the giving arguments sorted by their
+// priority.
+func (m *MQue) calls(vals []interface{}) []mqueCall {
+	types := make([]reflect.Type, len(vals))
+	for index, val := range vals {
+		types[index] = reflect.TypeOf(val)
+	}
+
+	matches := m.matches(types)
+
 	m.l.RLock()
 	defer m.l.RUnlock()
 
-	ctype := reflect.TypeOf(val)
+	// Add the any callbacks.
+	calls := m.any.calls(nil, nil)
+
+	// Add those who match.
+	for _, match := range matches {
+		calls = match.sub.calls(match.args(vals), calls)
+	}
+
+	sortCalls(calls)
+	return calls
+}
+
+// sortCalls sorts the callbacks by priority, keeping the order in which they
+// were added for equal priorities.
+func sortCalls(calls []mqueCall) {
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].fn.priority != calls[j].fn.priority {
+			return calls[i].fn.priority > calls[j].fn.priority
+		}
+
+		return calls[i].fn.seq < calls[j].fn.seq
+	})
+}
+
+// matches returns the subscribers which can be used with the giving argument
+// types, using the cache for the types if available.
+func (m *MQue) matches(types []reflect.Type) []mqueMatch {
+	key := dispatchKey(types)
 
-	// Run the any callbacks.
-	m.any.Run(val, ctype)
+	m.cl.RLock()
+	matches, ok := m.cache[key]
+	m.cl.RUnlock()
+
+	if ok {
+		return matches
+	}
+
+	m.l.RLock()
+	defer m.l.RUnlock()
 
-	// Check and Run those who match.
 	for _, mux := range m.muxers {
-		if mux.CanRun(ctype) {
-			mux.Run(val, ctype)
+		if mux.match(types) {
+			matches = append(matches, mqueMatch{sub: mux})
 		}
 	}
+
+	m.cl.Lock()
+	m.cache[key] = matches
+	m.cl.Unlock()
+
+	return matches
+}
+
+// reset clears the cache of matched subscribers, expects the queue lock to be
+// held so no stale matches are cached.
+func (m *MQue) reset() {
+	m.cl.Lock()
+	m.cache = make(map[reflect.Type][]mqueMatch)
+	m.cl.Unlock()
+}
+
+// dispatchKey returns the type used to cache the subscribers matching the
+// giving argument types. A single non-nil argument uses its own type, else a
+// function type with the argument types as parameters is used.
+func dispatchKey(types []reflect.Type) reflect.Type {
+	if len(types) == 1 && types[0] != nil {
+		return types[0]
+	}
+
+	in := make([]reflect.Type, len(types))
+	for index, item := range types {
+		if item == nil {
+			item = dispatchType
+		}
+
+		in[index] = item
+	}
+
+	return reflect.FuncOf(in, []reflect.Type{dispatchType}, false)
 }
 
 // Q adds a new function type into the queue.
 func (m *MQue) Q(mx interface{}, rmx ...func()) End {
+	return m.Subscribe(Options{}, mx, rmx...)
+}
+
+// Once adds a new function type into the queue which is ended after it has
+// been called once.
+func (m *MQue) Once(mx interface{}, rmx ...func()) End {
+	return m.Subscribe(Options{Once: true}, mx, rmx...)
+}
+
+// Subscribe adds a new function type into the queue using the giving options.
+// The function is called when the published arguments can be used for all its
+// parameters, either by assignment, including interface implementation, or by
+// conversion between types of the same kind. A function without parameters is
+// called for all published arguments. The function may return an error which
+// will be reported by RunE.
+func (m *MQue) Subscribe(ops Options, mx interface{}, rmx ...func()) End {
 	if !reflection.IsFuncType(mx) {
 		return nil
 	}
 
 	tm, _ := reflection.FuncValue(mx)
-
-	var hasArgs bool
-	var tu reflect.Type
+	ttype := tm.Type()
+
+	fn := &mqueFn{
+		fn:       tm,
+		seq:      atomic.AddInt64(&m.seq, 1),
+		priority: ops.Priority,
+		once:     ops.Once,
+		errs:     ttype.NumOut() > 0 && ttype.Out(ttype.NumOut()-1) == errorType,
+	}
 
 	args, _ := reflection.GetFuncArgumentsType(mx)
-	if size := len(args); size != 0 {
-		tu = args[0]
-		hasArgs = true
-	}
 
-	if !hasArgs {
-		index := len(m.any.tms)
-		m.any.tms = append(m.any.tms, tm)
+	m.l.Lock()
+	defer m.l.Unlock()
 
-		return &mqueSubIndex{
-			index:  index,
-			queue:  m.any,
-			ending: rmx,
-		}
-	}
+	sub := m.any
 
-	var sub *mqueSub
+	if len(args) != 0 {
+		sub = nil
 
-	m.l.RLock()
-	{
 		for _, tSub := range m.muxers {
-			if tSub.CanRun(tu) {
+			if tSub.Is(args) {
 				sub = tSub
 				break
 			}
 		}
 	}
-	m.l.RUnlock()
 
-	if sub != nil {
-		tindex := len(sub.tms)
-		sub.tms = append(sub.tms, tm)
+	if sub == nil {
+		sub = &mqueSub{am: args}
+		m.muxers = append(m.muxers, sub)
+		m.reset()
+	}
 
-		return &mqueSubIndex{
-			index:  tindex,
-			queue:  sub,
-			ending: rmx,
-		}
+	sub.tms = append(sub.tms, fn)
+
+	fn.sub = &mqueSubEnd{
+		fn:     fn,
+		mq:     m,
+		queue:  sub,
+		ending: rmx,
 	}
 
-	var mq mqueSub
-	mq.has = true
-	mq.am = tu
-	mq.tms = []reflect.Value{tm}
+	return fn.sub
+}
 
-	m.l.Lock()
-	m.muxers = append(m.muxers, &mq)
-	m.l.Unlock()
+// Len returns the total number of callbacks in the queue.
+func (m *MQue) Len() int {
+	m.l.RLock()
+	defer m.l.RUnlock()
 
-	return &mqueSubIndex{
-		index:  0,
-		queue:  &mq,
-		ending: rmx,
+	total := len(m.any.tms)
+	for _, mux := range m.muxers {
+		total += len(mux.tms)
 	}
+
+	return total
+}
+
+// Subscriber defines the details of a callback in the queue.
+type Subscriber struct {
+	ID       int64
+	Priority int
+	Once     bool
+	Func     reflect.Type
+}
+
+// Subscribers returns the callbacks which will be called for arguments of the
+// giving types, in the order they will be called. A nil type stands for a nil
+// argument.
+func (m *MQue) Subscribers(types ...reflect.Type) []Subscriber {
+	matches := m.matches(types)
+
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	calls := m.any.calls(nil, nil)
+	for _, match := range matches {
+		calls = match.sub.calls(nil, calls)
+	}
+
+	sortCalls(calls)
+
+	subs := make([]Subscriber, 0, len(calls))
+	for _, call := range calls {
+		subs = append(subs, Subscriber{
+			ID:       call.fn.seq,
+			Priority: call.fn.priority,
+			Once:     call.fn.once,
+			Func:     call.fn.fn.Type(),
+		})
+	}
+
+	return subs
 }
 
 //==============================================================================
 
-type mqueSubIndex struct {
-	index  int
+// mqueSubEnd defines the End returned for a callback added to a queue.
+type mqueSubEnd struct {
+	fn     *mqueFn
+	mq     *MQue
 	queue  *mqueSub
 	ending []func()
 }
 
+// ID returns the id of the subscription which is unique within its queue.
+func (m *mqueSubEnd) ID() int64 {
+	return m.fn.seq
+}
+
 // AddEnd adds the giving function to the end target.
-func (m *mqueSubIndex) AddEnd(fx func()) {
+func (m *mqueSubEnd) AddEnd(fx func()) {
+	m.mq.l.Lock()
+	defer m.mq.l.Unlock()
+
 	m.ending = append(m.ending, fx)
 }
 
 // End calls removes the listener type from the subscription queue.
-func (m *mqueSubIndex) End() {
+func (m *mqueSubEnd) End() {
+	m.mq.l.Lock()
 	if m.queue == nil {
+		m.mq.l.Unlock()
 		return
 	}
 
-	m.queue.tms = append(m.queue.tms[:m.index], m.queue.tms[m.index+1:]...)
-	for _, fx := range m.ending {
-		fx()
-	}
+	m.queue.remove(m.fn.seq)
+
+	ending := m.ending
 
 	m.ending = nil
 	m.queue = nil
+	m.mq.l.Unlock()
+
+	for _, fx := range ending {
+		fx()
+	}
+}
+
+// mqueFn defines a single callback added to a queue subscriber.
+type mqueFn struct {
+	fn       reflect.Value
+	sub      *mqueSubEnd
+	seq      int64
+	priority int
+	once     bool
+	errs     bool
+	fired    int32
+}
+
+// call calls the function with the giving arguments, returning its error if
+// it returns one.
+func (m *mqueFn) call(args []reflect.Value) error {
+	res := m.fn.Call(args)
+	if !m.errs {
+		return nil
+	}
+
+	if err, ok := res[len(res)-1].Interface().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// mqueCall defines a callback to be called with its arguments.
+type mqueCall struct {
+	fn   *mqueFn
+	args []reflect.Value
+}
+
+// mqueMatch defines a subscriber matching a set of argument types.
+type mqueMatch struct {
+	sub *mqueSub
+}
+
+// args returns the values for the subscriber parameters.
+func (m mqueMatch) args(vals []interface{}) []reflect.Value {
+	args := make([]reflect.Value, len(vals))
+
+	for index, val := range vals {
+		target := m.sub.am[index]
+
+		if val == nil {
+			args[index] = reflect.Zero(target)
+			continue
+		}
+
+		args[index] = reflect.ValueOf(val)
+	}
+
+	return args
 }
 
 // mqueSub defines a queue subscriber attached to a specific queue.
 type mqueSub struct {
-	has bool
-	am  reflect.Type
-	tms []reflect.Value
+	am  []reflect.Type
+	tms []*mqueFn
 }
 
 func (m *mqueSub) Flush() {
 	m.tms = nil
 }
 
-// CanRun returns whether the argument can be used with this subscriber.
-func (m *mqueSub) CanRun(d reflect.Type) bool {
-	if !d.AssignableTo(m.am) {
-		// if d.ConvertibleTo(m.am) {
-		// 	return true
-		// }
+// remove removes the callback with the giving id, expects the queue lock to be
+// held. A new slice is created so calls collected before remain valid.
+func (m *mqueSub) remove(id int64) {
+	for index, fn := range m.tms {
+		if fn.seq == id {
+			tms := make([]*mqueFn, 0, len(m.tms)-1)
+			tms = append(tms, m.tms[:index]...)
+			m.tms = append(tms, m.tms[index+1:]...)
+			return
+		}
+	}
+}
 
+// Is returns whether the subscriber has the exact giving parameter types.
+func (m *mqueSub) Is(args []reflect.Type) bool {
+	if len(args) != len(m.am) {
 		return false
 	}
 
+	for index, arg := range args {
+		if arg != m.am[index] {
+			return false
+		}
+	}
+
 	return true
 }
 
-// Run recevies the argument and
-func (m *mqueSub) Run(d interface{}, ctype reflect.Type) {
-	if !m.has {
-		for _, tm := range m.tms {
-			tm.Call([]reflect.Value{})
-		}
+// CanRun returns whether the arguments can be used with this subscriber.
+func (m *mqueSub) CanRun(d ...reflect.Type) bool {
+	return m.match(d)
+}
 
-		return
+// match returns whether the argument types can be used with this subscriber,
+// where each must be assignable to its parameter or implement it if it is an
+// interface. Nil arguments, giving as nil types, match parameters which can be
+// nil. Arguments are never converted, so distinct named types of the same
+// shape do not match each other.
+func (m *mqueSub) match(types []reflect.Type) bool {
+	if len(types) != len(m.am) {
+		return false
 	}
 
-	var configVal reflect.Value
+	for index, item := range types {
+		target := m.am[index]
 
-	if !ctype.AssignableTo(m.am) {
-		if !ctype.ConvertibleTo(m.am) {
-			return
+		if item == nil {
+			if !nillable(target) {
+				return false
+			}
+
+			continue
+		}
+
+		if item.AssignableTo(target) {
+			continue
 		}
 
-		vum := reflect.ValueOf(d)
-		configVal = vum.Convert(m.am)
-	} else {
-		configVal = reflect.ValueOf(d)
+		if target.Kind() == reflect.Interface && item.Implements(target) {
+			continue
+		}
+
+		return false
 	}
 
+	return true
+}
+
+// calls appends the callbacks of the subscriber with the arguments to use.
+func (m *mqueSub) calls(args []reflect.Value, calls []mqueCall) []mqueCall {
 	for _, tm := range m.tms {
-		tm.Call([]reflect.Value{configVal})
+		calls = append(calls, mqueCall{fn: tm, args: args})
+	}
+
+	return calls
+}
+
+// nillable returns true/false if the type can hold a nil value.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -1,7 +1,14 @@
 package mque_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/influx6/faux/mque"
 )
@@ -79,6 +86,20 @@ func BenchmarkQueue(b *testing.B) {
 	}
 }
 
+func BenchmarkQueueWithInterfaces(b *testing.B) {
+	b.ResetTimer()
+	defer b.ReportAllocs()
+
+	q := mque.New()
+
+	q.Q(func(item fmt.Stringer) {})
+	q.Q(func(item error) {})
+
+	for i := 0; i < b.N; i++ {
+		q.Run(queueName("bob"))
+	}
+}
+
 func BenchmarkQueueWithMultitypes(b *testing.B) {
 	b.ResetTimer()
 	defer b.ReportAllocs()
@@ -135,3 +156,479 @@ func TestQueueEnd(t *testing.T) {
 		}
 	}
 }
+
+// TestQueuePriority validates the ordering of callbacks by priority.
+func TestQueuePriority(t *testing.T) {
+	var order []string
+
+	q := mque.New()
+
+	q.Q(func(item int) { order = append(order, "default") })
+	q.Subscribe(mque.Options{Priority: 10}, func(item int) { order = append(order, "high") })
+	q.Subscribe(mque.Options{Priority: -1}, func() { order = append(order, "low") })
+	q.Subscribe(mque.Options{Priority: 10}, func() { order = append(order, "high-any") })
+
+	q.Run(1)
+
+	if strings.Join(order, ",") != "high,high-any,default,low" {
+		t.Fatalf("Should have called callbacks in priority order: %+q", order)
+	}
+	t.Logf("Should have called callbacks in priority order")
+}
+
+// TestQueueRunE validates the aggregation of errors and stopping propagation.
+func TestQueueRunE(t *testing.T) {
+	var called []int
+
+	q := mque.New()
+
+	q.Subscribe(mque.Options{Priority: 3}, func(item int) error {
+		called = append(called, 3)
+		return errors.New("first failed")
+	})
+
+	q.Subscribe(mque.Options{Priority: 2}, func(item int) error {
+		called = append(called, 2)
+		if item > 10 {
+			return mque.ErrStopPropagation
+		}
+
+		return errors.New("second failed")
+	})
+
+	q.Subscribe(mque.Options{Priority: 1}, func(item int) {
+		called = append(called, 1)
+	})
+
+	err := q.RunE(1)
+	errs, ok := err.(mque.Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Should have received both errors: %#v", err)
+	}
+	t.Logf("Should have received both errors")
+
+	if len(called) != 3 {
+		t.Fatalf("Should have called all callbacks: %+v", called)
+	}
+	t.Logf("Should have called all callbacks")
+
+	called = nil
+
+	err = q.RunE(20)
+	if errs, ok := err.(mque.Errors); !ok || len(errs) != 1 || errs[0].Error() != "first failed" {
+		t.Fatalf("Should have received only first error: %#v", err)
+	}
+	t.Logf("Should have received only first error")
+
+	if len(called) != 2 {
+		t.Fatalf("Should have stopped propagation after second callback: %+v", called)
+	}
+	t.Logf("Should have stopped propagation after second callback")
+
+	if err := mque.New().RunE(1); err != nil {
+		t.Fatalf("Should have received no error without callbacks: %+s", err)
+	}
+	t.Logf("Should have received no error without callbacks")
+}
+
+// TestQueueOnce validates the ending of once subscriptions.
+func TestQueueOnce(t *testing.T) {
+	var count, other int
+	var ended bool
+
+	q := mque.New()
+
+	q.Once(func(item int) {
+		count++
+	}, func() {
+		ended = true
+	})
+
+	q.Q(func(item int) {
+		other++
+	})
+
+	q.Run(1)
+	q.Run(2)
+
+	if count != 1 {
+		t.Fatalf("Should have called once subscription once: %d", count)
+	}
+	t.Logf("Should have called once subscription once")
+
+	if !ended {
+		t.Fatalf("Should have ended once subscription")
+	}
+	t.Logf("Should have ended once subscription")
+
+	if other != 2 {
+		t.Fatalf("Should have called other subscription twice: %d", other)
+	}
+	t.Logf("Should have called other subscription twice")
+}
+
+type queueError struct{ msg string }
+
+func (q *queueError) Error() string { return q.msg }
+
+type queueName string
+
+func (q queueName) String() string { return string(q) }
+
+type userCreated struct{ ID int }
+
+type userDeleted struct{ ID int }
+
+// TestQueueAssignable validates the dispatch of arguments to interface and
+// assignable parameters only.
+func TestQueueAssignable(t *testing.T) {
+	var errs, stringers, names, strs, ints int
+
+	q := mque.New()
+
+	q.Q(func(err error) { errs++ })
+	q.Q(func(s fmt.Stringer) { stringers++ })
+	q.Q(func(s string) { strs++ })
+	q.Q(func(n queueName) { names++ })
+
+	q.Run(&queueError{msg: "bad"})
+
+	if errs != 1 {
+		t.Fatalf("Should have dispatched concrete error to error callback")
+	}
+	t.Logf("Should have dispatched concrete error to error callback")
+
+	q.Run(queueName("bob"))
+
+	if stringers != 1 || names != 1 || strs != 0 {
+		t.Fatalf("Should have dispatched named string to stringer and named callbacks only: %d %d %d", stringers, names, strs)
+	}
+	t.Logf("Should have dispatched named string to stringer and named callbacks only")
+
+	q.Run(nil)
+
+	if errs != 2 || stringers != 2 || strs != 0 {
+		t.Fatalf("Should have dispatched nil only to nillable callbacks: %d %d %d", errs, stringers, strs)
+	}
+	t.Logf("Should have dispatched nil only to nillable callbacks")
+
+	// Subscribing after a dispatch must not be hidden by the cache.
+	q.Q(func(item int) { ints++ })
+	q.Run(20)
+	q.Run("alex")
+
+	if ints != 1 || strs != 1 {
+		t.Fatalf("Should have dispatched to callbacks added after caching: %d %d", ints, strs)
+	}
+	t.Logf("Should have dispatched to callbacks added after caching")
+
+	if names != 1 {
+		t.Fatalf("Should not have dispatched plain string to named type callback: %d", names)
+	}
+	t.Logf("Should not have dispatched plain string to named type callback")
+
+	var created, deleted int
+	q.Q(func(userCreated) { created++ })
+	q.Q(func(userDeleted) { deleted++ })
+
+	q.Run(userDeleted{ID: 1})
+
+	if created != 0 || deleted != 1 {
+		t.Fatalf("Should have dispatched only to callback of the same named type: %d %d", created, deleted)
+	}
+	t.Logf("Should have dispatched only to callback of the same named type")
+}
+
+// TestQueueMultipleArguments validates the dispatch of many arguments.
+func TestQueueMultipleArguments(t *testing.T) {
+	var pairs []string
+	var singles, any int
+
+	q := mque.New()
+
+	q.Q(func(name string, age int) {
+		pairs = append(pairs, fmt.Sprintf("%s:%d", name, age))
+	})
+
+	q.Q(func(name string) { singles++ })
+	q.Q(func() { any++ })
+
+	q.Run("alex", 20)
+	q.Run("bob")
+	q.Run(20, "alex")
+
+	if len(pairs) != 1 || pairs[0] != "alex:20" {
+		t.Fatalf("Should have dispatched matching arguments only: %+q", pairs)
+	}
+	t.Logf("Should have dispatched matching arguments only")
+
+	if singles != 1 || any != 3 {
+		t.Fatalf("Should have dispatched by argument count: %d %d", singles, any)
+	}
+	t.Logf("Should have dispatched by argument count")
+}
+
+// TestQueuePanics validates the recovery of panicking callbacks.
+func TestQueuePanics(t *testing.T) {
+	var reported []error
+	var called bool
+
+	q := mque.NewWithConfig(mque.Config{
+		OnError: func(err error) { reported = append(reported, err) },
+	})
+
+	q.Subscribe(mque.Options{Priority: 1}, func(item int) {
+		panic("bad callback")
+	})
+
+	q.Q(func(item int) { called = true })
+
+	err := q.RunE(1)
+	errs, ok := err.(mque.Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Should have returned recovered panic: %#v", err)
+	}
+
+	if perr, ok := errs[0].(*mque.PanicError); !ok || perr.Value != "bad callback" || len(perr.Stack) == 0 {
+		t.Fatalf("Should have returned a PanicError: %#v", errs[0])
+	}
+	t.Logf("Should have returned recovered panic")
+
+	if len(reported) != 1 {
+		t.Fatalf("Should have reported panic to error hook: %+v", reported)
+	}
+	t.Logf("Should have reported panic to error hook")
+
+	if !called {
+		t.Fatalf("Should have called remaining callbacks")
+	}
+	t.Logf("Should have called remaining callbacks")
+}
+
+// TestQueueGoroutineMode validates running callbacks in goroutines.
+func TestQueueGoroutineMode(t *testing.T) {
+	var count int32
+	var failures int32
+
+	release := make(chan struct{})
+
+	q := mque.NewWithConfig(mque.Config{
+		Mode:    mque.Goroutine,
+		OnError: func(err error) { atomic.AddInt32(&failures, 1) },
+	})
+
+	q.Q(func(item int) error {
+		<-release
+		atomic.AddInt32(&count, 1)
+		return errors.New("failed")
+	})
+
+	q.Q(func(item int) {
+		panic("bad callback")
+	})
+
+	for i := 0; i < 10; i++ {
+		if err := q.RunE(i); err != nil {
+			t.Fatalf("Should have returned no error from RunE: %+s", err)
+		}
+	}
+	t.Logf("Should have published without waiting for slow callbacks")
+
+	close(release)
+	q.Wait()
+
+	if atomic.LoadInt32(&count) != 10 {
+		t.Fatalf("Should have finished all callbacks after Wait: %d", count)
+	}
+	t.Logf("Should have finished all callbacks after Wait")
+
+	if atomic.LoadInt32(&failures) != 20 {
+		t.Fatalf("Should have reported all errors and panics: %d", failures)
+	}
+	t.Logf("Should have reported all errors and panics")
+}
+
+// TestQueuePoolMode validates running callbacks on a bounded worker pool.
+func TestQueuePoolMode(t *testing.T) {
+	var running, max, count int32
+
+	q := mque.NewWithConfig(mque.Config{
+		Mode:      mque.Pool,
+		Workers:   2,
+		QueueSize: 5,
+	})
+	defer q.Close()
+
+	q.Q(func(item int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&max)
+			if current <= seen || atomic.CompareAndSwapInt32(&max, seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&count, 1)
+	})
+
+	for i := 0; i < 20; i++ {
+		q.Run(i)
+	}
+
+	q.Wait()
+
+	if atomic.LoadInt32(&count) != 20 {
+		t.Fatalf("Should have finished all callbacks after Wait: %d", count)
+	}
+	t.Logf("Should have finished all callbacks after Wait")
+
+	if atomic.LoadInt32(&max) > 2 {
+		t.Fatalf("Should have run at most two callbacks at once: %d", max)
+	}
+	t.Logf("Should have run at most two callbacks at once")
+}
+
+// TestQueuePoolRepublish validates closing a Pool queue whose callbacks
+// publish into it while it is full.
+func TestQueuePoolRepublish(t *testing.T) {
+	var count int32
+
+	q := mque.NewWithConfig(mque.Config{Mode: mque.Pool, Workers: 1})
+
+	q.Q(func(item int) {
+		atomic.AddInt32(&count, 1)
+		if item < 3 {
+			q.Run(item + 1)
+		}
+	})
+
+	q.Run(0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Close()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Should have closed queue with callbacks waiting on a full queue")
+	}
+	t.Logf("Should have closed queue with callbacks waiting on a full queue")
+
+	if atomic.LoadInt32(&count) != 4 {
+		t.Fatalf("Should have run all published callbacks: %d", count)
+	}
+	t.Logf("Should have run all published callbacks")
+}
+
+// TestQueuePanicsWithoutHook validates panics of callbacks are not recovered
+// without an error hook.
+func TestQueuePanicsWithoutHook(t *testing.T) {
+	q := mque.New()
+
+	q.Q(func(item int) {
+		panic("bad callback")
+	})
+
+	defer func() {
+		if ex := recover(); ex != "bad callback" {
+			t.Fatalf("Should have propagated callback panic: %#v", ex)
+		}
+		t.Logf("Should have propagated callback panic")
+	}()
+
+	q.Run(1)
+	t.Fatalf("Should have propagated callback panic")
+}
+
+// TestQueueEndOrder validates ending subscriptions in any order.
+func TestQueueEndOrder(t *testing.T) {
+	var fired []string
+
+	q := mque.New()
+
+	first := q.Q(func(item int) { fired = append(fired, "first") })
+	second := q.Q(func(item int) { fired = append(fired, "second") })
+	q.Q(func(item int) { fired = append(fired, "third") })
+
+	if first.ID() == second.ID() {
+		t.Fatalf("Should have received unique subscription ids")
+	}
+	t.Logf("Should have received unique subscription ids")
+
+	first.End()
+	second.End()
+	first.End()
+
+	q.Run(1)
+
+	if strings.Join(fired, ",") != "third" {
+		t.Fatalf("Should have fired only the remaining subscription: %+q", fired)
+	}
+	t.Logf("Should have fired only the remaining subscription")
+
+	if q.Len() != 1 {
+		t.Fatalf("Should have one subscription left: %d", q.Len())
+	}
+	t.Logf("Should have one subscription left")
+}
+
+// TestQueueSubscribers validates the introspection of subscriptions.
+func TestQueueSubscribers(t *testing.T) {
+	q := mque.New()
+
+	any := q.Q(func() {})
+	str := q.Subscribe(mque.Options{Priority: 5, Once: true}, func(item fmt.Stringer) {})
+	q.Q(func(item int) {})
+
+	if q.Len() != 3 {
+		t.Fatalf("Should have three subscriptions: %d", q.Len())
+	}
+	t.Logf("Should have three subscriptions")
+
+	subs := q.Subscribers(reflect.TypeOf(queueName("")))
+	if len(subs) != 2 || subs[0].ID != str.ID() || !subs[0].Once || subs[1].ID != any.ID() {
+		t.Fatalf("Should have listed stringer and any subscriptions in call order: %+v", subs)
+	}
+	t.Logf("Should have listed stringer and any subscriptions in call order")
+}
+
+// TestQueueConcurrentSubscriptions validates concurrent Q, End and Run calls.
+func TestQueueConcurrentSubscriptions(t *testing.T) {
+	var wg sync.WaitGroup
+	var calls int32
+
+	q := mque.New()
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				sub := q.Q(func(item int) { atomic.AddInt32(&calls, 1) })
+				any := q.Q(func() {})
+				q.Run(j)
+				sub.End()
+				any.End()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if q.Len() != 0 {
+		t.Fatalf("Should have ended all subscriptions: %d", q.Len())
+	}
+	t.Logf("Should have ended all subscriptions")
+
+	if atomic.LoadInt32(&calls) < 800 {
+		t.Fatalf("Should have called each subscription at least once: %d", calls)
+	}
+	t.Logf("Should have called each subscription at least once")
+}
@@ -0,0 +1,343 @@
+package pattern
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IssueKind defines the kind of problem found by Analyze.
+type IssueKind string
+
+// contains the kinds of issues reported by Analyze.
+const (
+	// IssueInvalid reports a pattern which can not be compiled.
+	IssueInvalid IssueKind = "invalid"
+
+	// IssueDuplicateParam reports a pattern using a param name more than once.
+	IssueDuplicateParam IssueKind = "duplicate-param"
+
+	// IssueUnreachable reports a pattern which can never be selected because
+	// an earlier pattern has the same shape.
+	IssueUnreachable IssueKind = "unreachable"
+
+	// IssueAmbiguous reports two patterns which can match the same path where
+	// the winner only depends on registration order.
+	IssueAmbiguous IssueKind = "ambiguous"
+
+	// IssueOverlap reports two patterns which can match the same path where
+	// the winner is decided by the CheckPriority rules.
+	IssueOverlap IssueKind = "overlap"
+)
+
+// Issue defines a single problem found by Analyze, where the methods are
+// empty for patterns registered for all methods.
+type Issue struct {
+	Kind        IssueKind `json:"kind"`
+	Method      string    `json:"method,omitempty"`
+	Pattern     string    `json:"pattern"`
+	OtherMethod string    `json:"other_method,omitempty"`
+	Other       string    `json:"other,omitempty"`
+	Message     string    `json:"message"`
+}
+
+// String returns a readable form of the issue.
+func (i Issue) String() string {
+	if i.Other != "" {
+		return fmt.Sprintf("%s: %s <> %s: %s", i.Kind, withMethod(i.Method, i.Pattern), withMethod(i.OtherMethod, i.Other), i.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", i.Kind, withMethod(i.Method, i.Pattern), i.Message)
+}
+
+// withMethod returns the pattern prefixed by its method if any.
+func withMethod(method string, pattern string) string {
+	if method == "" {
+		return pattern
+	}
+
+	return method + " " + pattern
+}
+
+// IsError returns true/false if the issue should fail a check, which is true
+// for all kinds but IssueOverlap.
+func (i Issue) IsError() bool {
+	return i.Kind != IssueOverlap
+}
+
+// samples contains values used to probe if two parameter segments can match
+// the same value, together with the static segments of the analyzed set.
+var samples = []string{
+	"0", "1", "12", "-1", "1.5", "a", "abc", "ABC", "a-b", "a_b", "a.b",
+	"2017-03-04", "2017-03-04T10:20:30Z", "123e4567-e89b-12d3-a456-426614174000",
+	"abc123", "%20", "~",
+}
+
+// shape defines the compiled segments of an analyzed pattern.
+type shape struct {
+	method   string
+	pattern  string
+	endless  bool
+	segments []*SegmentMatcher
+}
+
+// segment classes following the ordering used by CheckPriority and Router.
+const (
+	classStatic = iota
+	classRegex
+	classPick
+)
+
+func classOf(s *SegmentMatcher) int {
+	switch {
+	case !s.IsParam():
+		return classStatic
+	case s.Expr() == anyvalue:
+		return classPick
+	default:
+		return classRegex
+	}
+}
+
+// Analyze reports invalid patterns, duplicate param names, unreachable
+// patterns and overlaps between the giving patterns, which are expected in
+// registration order and are registered for all methods, see AnalyzeRoutes.
+// Overlaps between parameter segments are detected by probing both segments
+// with a set of sample values, so it may miss overlaps for unusual regexps.
+func Analyze(patterns ...string) []Issue {
+	routes := make([]Route, len(patterns))
+	for index, patt := range patterns {
+		routes[index] = Route{Pattern: patt}
+	}
+
+	return AnalyzeRoutes(routes...)
+}
+
+// AnalyzeRoutes reports issues between the giving routes like Analyze, using
+// only their Method and Pattern. Routes only conflict if they have the same
+// method or if either is registered for all methods with an empty method or
+// AnyMethod, so a route table like Router.Routes can be checked as is.
+func AnalyzeRoutes(routes ...Route) []Issue {
+	var issues []Issue
+	var shapes []shape
+
+	statics := make(map[string]bool)
+
+	for _, route := range routes {
+		patt := route.Pattern
+
+		method := strings.ToUpper(route.Method)
+		if method == AnyMethod {
+			method = ""
+		}
+
+		matcher, err := Compile(patt)
+		if err != nil {
+			issues = append(issues, Issue{Kind: IssueInvalid, Method: method, Pattern: patt, Message: err.Error()})
+			continue
+		}
+
+		sh := shape{
+			method:  method,
+			pattern: matcher.Pattern(),
+			endless: IsEndless(matcher.Pattern()),
+		}
+
+		seen := make(map[string]bool)
+
+		for _, item := range SegmentList(matcher.Pattern()) {
+			sm := item.(*SegmentMatcher)
+			sh.segments = append(sh.segments, sm)
+
+			if !sm.IsParam() {
+				statics[sm.Segment()] = true
+				continue
+			}
+
+			if seen[sm.Segment()] {
+				issues = append(issues, Issue{
+					Kind:    IssueDuplicateParam,
+					Method:  method,
+					Pattern: patt,
+					Message: fmt.Sprintf("param %q is used more than once", sm.Segment()),
+				})
+			}
+
+			seen[sm.Segment()] = true
+		}
+
+		shapes = append(shapes, sh)
+	}
+
+	probes := append([]string{}, samples...)
+	for static := range statics {
+		probes = append(probes, static)
+	}
+
+	sort.Strings(probes)
+
+	for i := 0; i < len(shapes); i++ {
+		for j := i + 1; j < len(shapes); j++ {
+			first, second := shapes[i], shapes[j]
+			if first.method != "" && second.method != "" && first.method != second.method {
+				continue
+			}
+
+			issue, ok := compare(first, second, probes)
+			if !ok {
+				continue
+			}
+
+			// A route for a single method wins over a route for all methods
+			// with the same segments, which still serves the other methods.
+			if first.method != second.method && issue.Kind != IssueOverlap {
+				method := first.method + second.method
+				issue.Kind = IssueOverlap
+				issue.Message = fmt.Sprintf("has the same segments, the %s route wins for %s requests", method, method)
+			}
+
+			issue.Method, issue.OtherMethod = second.method, first.method
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// compare returns the issue between two shapes, where first was registered
+// before second.
+func compare(first, second shape, probes []string) (Issue, bool) {
+	if first.endless != second.endless || len(first.segments) != len(second.segments) {
+		return compareEndless(first, second, probes)
+	}
+
+	identical := true
+	sameClass := true
+
+	for index := range first.segments {
+		a, b := first.segments[index], second.segments[index]
+
+		if !overlaps(a, b, probes) {
+			return Issue{}, false
+		}
+
+		if classOf(a) != classOf(b) {
+			sameClass = false
+			identical = false
+			continue
+		}
+
+		if classOf(a) == classStatic && a.Segment() != b.Segment() {
+			identical = false
+		}
+
+		if classOf(a) != classStatic && a.Expr() != b.Expr() {
+			identical = false
+		}
+	}
+
+	switch {
+	case identical:
+		return Issue{
+			Kind:    IssueUnreachable,
+			Pattern: second.pattern,
+			Other:   first.pattern,
+			Message: "has the same segments as an earlier pattern and can never be selected",
+		}, true
+	case sameClass:
+		return Issue{
+			Kind:    IssueAmbiguous,
+			Pattern: second.pattern,
+			Other:   first.pattern,
+			Message: "can match the same paths with equal priority, registration order decides",
+		}, true
+	default:
+		return Issue{
+			Kind:    IssueOverlap,
+			Pattern: second.pattern,
+			Other:   first.pattern,
+			Message: fmt.Sprintf("can match the same paths, %s wins by priority", winner(first, second)),
+		}, true
+	}
+}
+
+// compareEndless reports overlaps where an endless pattern shares a prefix
+// with another pattern.
+func compareEndless(first, second shape, probes []string) (Issue, bool) {
+	short, long := first, second
+	if !short.endless || (long.endless && len(long.segments) < len(short.segments)) {
+		short, long = second, first
+	}
+
+	if !short.endless || len(short.segments) > len(long.segments) {
+		return Issue{}, false
+	}
+
+	for index := range short.segments {
+		if !overlaps(short.segments[index], long.segments[index], probes) {
+			return Issue{}, false
+		}
+	}
+
+	return Issue{
+		Kind:    IssueOverlap,
+		Pattern: second.pattern,
+		Other:   first.pattern,
+		Message: fmt.Sprintf("can match the same paths, %s wins as endless patterns are tried last", long.pattern),
+	}, true
+}
+
+// winner returns the pattern selected by the Router when both match, which is
+// decided by the segment class at the first position where they differ.
+func winner(first, second shape) string {
+	for index := range first.segments {
+		a, b := classOf(first.segments[index]), classOf(second.segments[index])
+		if a < b {
+			return first.pattern
+		}
+
+		if b < a {
+			return second.pattern
+		}
+	}
+
+	return first.pattern
+}
+
+// overlaps returns true/false if both segments can match the same value.
+func overlaps(a, b *SegmentMatcher, probes []string) bool {
+	if !a.IsParam() && !b.IsParam() {
+		return a.Segment() == b.Segment()
+	}
+
+	if !a.IsParam() {
+		return b.Validate(a.Segment())
+	}
+
+	if !b.IsParam() {
+		return a.Validate(b.Segment())
+	}
+
+	if a.Expr() == b.Expr() {
+		return true
+	}
+
+	for _, probe := range probes {
+		if a.Validate(probe) && b.Validate(probe) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatIssues returns the issues as a readable report with one issue per
+// line.
+func FormatIssues(issues []Issue) string {
+	lines := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
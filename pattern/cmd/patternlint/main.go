@@ -0,0 +1,86 @@
+// Command patternlint analyzes a set of pattern routes and reports invalid
+// patterns, duplicate param names, unreachable patterns and overlaps.
+//
+// Patterns are read one per line from the giving files or from stdin when no
+// file is provided, with empty lines and lines starting with // ignored. A
+// line may start with a method, e.g "GET /users/:id", where routes only
+// conflict with routes of the same method or of no method.
+//
+//	patternlint routes.txt
+//	patternlint -strict -json < routes.txt
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/influx6/faux/pattern"
+)
+
+func main() {
+	strict := flag.Bool("strict", false, "fail on overlaps resolved by priority")
+	asJSON := flag.Bool("json", false, "print issues as json")
+	flag.Parse()
+
+	var routes []pattern.Route
+
+	if flag.NArg() == 0 {
+		routes = read(os.Stdin, routes)
+	}
+
+	for _, path := range flag.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "patternlint: %s\n", err)
+			os.Exit(2)
+		}
+
+		routes = read(file, routes)
+		file.Close()
+	}
+
+	issues := pattern.AnalyzeRoutes(routes...)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(issues)
+	} else if len(issues) > 0 {
+		fmt.Println(pattern.FormatIssues(issues))
+	}
+
+	for _, issue := range issues {
+		if *strict || issue.IsError() {
+			os.Exit(1)
+		}
+	}
+}
+
+// read appends the routes found in the giving reader.
+func read(r io.Reader, routes []pattern.Route) []pattern.Route {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		var route pattern.Route
+
+		if fields := strings.Fields(line); len(fields) == 2 {
+			route.Method, route.Pattern = fields[0], fields[1]
+		} else {
+			route.Pattern = line
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes
+}
@@ -0,0 +1,164 @@
+package pattern
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateLayout defines the layout of values matched by the date constraint.
+const DateLayout = "2006-01-02"
+
+// Constraint defines a named segment constraint which can be used in place of
+// a regexp in a pattern, e.g {id:int}, {slug:slug} or {page:int(1,100)}. A
+// name which is not registered is used as a regexp as before. Prefixing the
+// name with @, e.g {id:@int}, requires a registered constraint, so a pattern
+// naming an unknown one fails to compile.
+type Constraint struct {
+	// Pattern sets the regexp which a value must fully match.
+	Pattern string
+
+	// Check optionally validates a matched value using the arguments provided
+	// in parenthesis within the pattern, e.g the 1 and 100 in int(1,100).
+	Check func(value string, args []string) error
+}
+
+var constraints = struct {
+	cl    sync.RWMutex
+	items map[string]Constraint
+}{
+	items: map[string]Constraint{
+		"int": {
+			Pattern: `-?\d+`,
+			Check:   checkIntRange,
+		},
+		"float": {
+			Pattern: `-?\d+(?:\.\d+)?`,
+			Check:   checkFloatRange,
+		},
+		"alpha": {
+			Pattern: `[A-Za-z]+`,
+		},
+		"slug": {
+			Pattern: `[a-z0-9]+(?:-[a-z0-9]+)*`,
+		},
+		"uuid": {
+			Pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+		},
+		"date": {
+			Pattern: `\d{4}-\d{2}-\d{2}`,
+			Check: func(value string, _ []string) error {
+				_, err := time.Parse(DateLayout, value)
+				return err
+			},
+		},
+	},
+}
+
+// RegisterConstraint adds the giving constraint under the provided name,
+// replacing any existing constraint with the same name. It returns an error if
+// the constraint pattern is not a valid regexp.
+func RegisterConstraint(name string, c Constraint) error {
+	if _, err := regexp.Compile(c.Pattern); err != nil {
+		return fmt.Errorf("pattern: invalid constraint %q: %s", name, err)
+	}
+
+	constraints.cl.Lock()
+	defer constraints.cl.Unlock()
+
+	constraints.items[name] = c
+	return nil
+}
+
+// GetConstraint returns the constraint registered under the giving name.
+func GetConstraint(name string) (Constraint, bool) {
+	constraints.cl.RLock()
+	defer constraints.cl.RUnlock()
+
+	c, ok := constraints.items[name]
+	return c, ok
+}
+
+var constraintExpr = regexp.MustCompile(`^(@?)(\w+)(?:\(([^)]*)\))?$`)
+
+// ParseConstraint returns the constraint and its arguments if the giving
+// segment expression, such as int, int(1,100) or @int, names a registered
+// constraint. It returns an error if the expression names an unknown
+// constraint with the @ prefix.
+func ParseConstraint(expr string) (Constraint, []string, bool, error) {
+	parts := constraintExpr.FindStringSubmatch(expr)
+	if parts == nil {
+		return Constraint{}, nil, false, nil
+	}
+
+	c, ok := GetConstraint(parts[2])
+	if !ok {
+		if parts[1] != "" {
+			return Constraint{}, nil, false, fmt.Errorf("pattern: unknown constraint %q", parts[2])
+		}
+
+		return Constraint{}, nil, false, nil
+	}
+
+	var args []string
+	if parts[3] != "" {
+		for _, arg := range strings.Split(parts[3], ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	}
+
+	return c, args, true, nil
+}
+
+// checkIntRange validates an integer against the optional min and max args.
+func checkIntRange(value string, args []string) error {
+	val, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	bounds := make([]int64, len(args))
+	for index, arg := range args {
+		if bounds[index], err = strconv.ParseInt(arg, 10, 64); err != nil {
+			return fmt.Errorf("invalid int bound %q: %s", arg, err)
+		}
+	}
+
+	if len(bounds) > 0 && val < bounds[0] {
+		return fmt.Errorf("%d is less than %d", val, bounds[0])
+	}
+
+	if len(bounds) > 1 && val > bounds[1] {
+		return fmt.Errorf("%d is greater than %d", val, bounds[1])
+	}
+
+	return nil
+}
+
+// checkFloatRange validates a float against the optional min and max args.
+func checkFloatRange(value string, args []string) error {
+	val, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+
+	bounds := make([]float64, len(args))
+	for index, arg := range args {
+		if bounds[index], err = strconv.ParseFloat(arg, 64); err != nil {
+			return fmt.Errorf("invalid float bound %q: %s", arg, err)
+		}
+	}
+
+	if len(bounds) > 0 && val < bounds[0] {
+		return fmt.Errorf("%g is less than %g", val, bounds[0])
+	}
+
+	if len(bounds) > 1 && val > bounds[1] {
+		return fmt.Errorf("%g is greater than %g", val, bounds[1])
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package pattern
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/influx6/faux/reflection"
+)
+
+// ParamTag defines the struct tag used by Decode to map params to fields.
+const ParamTag = "param"
+
+// ErrNotStructPointer is returned by Decode when the target is not a pointer
+// to a struct.
+var ErrNotStructPointer = errors.New("pattern: decode target must be a pointer to a struct")
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decode fills the fields of the struct pointed to by dst from the giving
+// Params, using the `param` tag of each field or its lowercased name. Values
+// are converted to the field type, time.Time fields are parsed with DateLayout
+// or time.RFC3339 and fields implementing encoding.TextUnmarshaler decode
+// themselves. Params without a matching field are ignored.
+func Decode(params Params, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPointer
+	}
+
+	fields, err := reflection.GetTagFields(dst, ParamTag, true)
+	if err != nil {
+		return err
+	}
+
+	target := rv.Elem()
+
+	for _, field := range fields {
+		value, ok := params[field.Tag]
+		if !ok {
+			continue
+		}
+
+		fl := target.Field(field.Index)
+		if !fl.CanSet() {
+			continue
+		}
+
+		if err := decodeValue(fl, value); err != nil {
+			return fmt.Errorf("pattern: param %q into field %s: %s", field.Tag, field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeValue converts the giving string into the type of the field.
+func decodeValue(fl reflect.Value, value string) error {
+	if fl.Kind() == reflect.Ptr {
+		item := reflect.New(fl.Type().Elem())
+		if err := decodeValue(item.Elem(), value); err != nil {
+			return err
+		}
+
+		fl.Set(item)
+		return nil
+	}
+
+	if fl.Type() == timeType {
+		stamp, err := time.Parse(DateLayout, value)
+		if err != nil {
+			if stamp, err = time.Parse(time.RFC3339, value); err != nil {
+				return err
+			}
+		}
+
+		fl.Set(reflect.ValueOf(stamp))
+		return nil
+	}
+
+	if fl.CanAddr() && fl.Addr().Type().Implements(unmarshalerType) {
+		return fl.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	var parsed interface{}
+	var err error
+
+	switch fl.Kind() {
+	case reflect.String:
+		parsed = value
+	case reflect.Bool:
+		parsed, err = strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var val int64
+		if val, err = strconv.ParseInt(value, 10, fl.Type().Bits()); err == nil {
+			parsed = val
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var val uint64
+		if val, err = strconv.ParseUint(value, 10, fl.Type().Bits()); err == nil {
+			parsed = val
+		}
+	case reflect.Float32, reflect.Float64:
+		var val float64
+		if val, err = strconv.ParseFloat(value, fl.Type().Bits()); err == nil {
+			parsed = val
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", fl.Type())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(parsed)
+
+	canSet, mustConvert := reflection.CanSetFor(fl.Type(), val)
+	if !canSet {
+		return fmt.Errorf("can not set %s to field type %s", val.Type(), fl.Type())
+	}
+
+	if mustConvert {
+		if val, err = reflection.Convert(fl.Type(), val); err != nil {
+			return err
+		}
+	}
+
+	fl.Set(val)
+	return nil
+}
@@ -10,7 +10,10 @@
 package pattern
 
 import (
+	"fmt"
+	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -31,10 +34,22 @@ type Matchers []Matchable
 // URIMatcher defines an interface for a URI matcher.
 type URIMatcher interface {
 	Validate(string) (Params, string, bool)
+	Build(Params) (string, error)
 	Pattern() string
 	Priority() int
 }
 
+// contains special keys used by Build.
+const (
+	// RestKey defines the Params key whose value is appended to the path
+	// built for an endless pattern.
+	RestKey = "*"
+
+	// FragmentKey defines the Params key whose value is added as the
+	// #fragment of a built path.
+	FragmentKey = "#"
+)
+
 // matchProvider provides a class array-path matcher
 type matchProvider struct {
 	pattern  string
@@ -59,6 +74,19 @@ func New(pattern string) URIMatcher {
 	return &m
 }
 
+// Compile returns a new URIMatcher like New but returns an error instead of
+// panicking when a segment of the pattern has an invalid regexp or names an
+// unknown constraint with the @ prefix.
+func Compile(pattern string) (matcher URIMatcher, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			err = fmt.Errorf("pattern: invalid pattern %q: %v", pattern, ex)
+		}
+	}()
+
+	return New(pattern), nil
+}
+
 // Priority returns the priority status of this giving pattern.
 func (m *matchProvider) Priority() int {
 	return m.priority
@@ -143,6 +171,95 @@ func (m *matchProvider) Validate(path string) (Params, string, bool) {
 	return param, addSlash(strings.Join(rems, "/")), state
 }
 
+// Build returns the path for this pattern using the giving Params for its
+// parameter segments. Each value must fully match the regexp of its segment.
+// Hashed segments are written with a '#' as Validate expects them, the value
+// of RestKey is appended for endless patterns, the value of FragmentKey is
+// added as the fragment and all other params are added as a query string.
+func (m *matchProvider) Build(params Params) (string, error) {
+	used := make(map[string]bool)
+
+	var bu strings.Builder
+	var hashed bool
+
+	for index, v := range m.matchers {
+		if index == 0 && v.Segment() == "/" {
+			continue
+		}
+
+		if v.HasHash() {
+			hashed = true
+			bu.WriteString("#")
+		} else {
+			bu.WriteString("/")
+		}
+
+		if !v.IsParam() {
+			bu.WriteString(v.Segment())
+			continue
+		}
+
+		value, ok := params[v.Segment()]
+		if !ok {
+			return "", fmt.Errorf("pattern: missing param %q for %s", v.Segment(), m.pattern)
+		}
+
+		if sm, ok := v.(*SegmentMatcher); ok {
+			if err := sm.Check(value); err != nil {
+				return "", fmt.Errorf("pattern: param %q in %s: %s", v.Segment(), m.pattern, err)
+			}
+		}
+
+		used[v.Segment()] = true
+		bu.WriteString(url.PathEscape(value))
+	}
+
+	if rest, ok := params[RestKey]; ok {
+		if !m.endless {
+			return "", fmt.Errorf("pattern: %s is not endless but rest path was provided", m.pattern)
+		}
+
+		used[RestKey] = true
+		if rest = strings.Trim(rest, "/"); rest != "" {
+			bu.WriteString("/")
+			bu.WriteString(rest)
+		}
+	}
+
+	path := bu.String()
+	if path == "" {
+		path = "/"
+	}
+
+	var keys []string
+	for key := range params {
+		if !used[key] && key != FragmentKey {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) > 0 {
+		sort.Strings(keys)
+
+		query := make(url.Values, len(keys))
+		for _, key := range keys {
+			query.Set(key, params[key])
+		}
+
+		path += "?" + query.Encode()
+	}
+
+	if fragment, ok := params[FragmentKey]; ok && fragment != "" {
+		if hashed {
+			return "", fmt.Errorf("pattern: %s already contains a hash segment", m.pattern)
+		}
+
+		path += "#" + url.PathEscape(fragment)
+	}
+
+	return path, nil
+}
+
 //==============================================================================
 
 // SegmentList returns list of SegmentMatcher which implements the Matchable
@@ -176,9 +293,13 @@ func SegmentList(pattern string) Matchers {
 // SegmentMatcher defines a single piece of pattern to be matched against.
 type SegmentMatcher struct {
 	*regexp.Regexp
-	original string
-	param    bool
-	hashed   bool
+	full       *regexp.Regexp
+	expr       string
+	constraint *Constraint
+	args       []string
+	original   string
+	param      bool
+	hashed     bool
 }
 
 // Segment returns a Matchable for a specific part of a pattern eg. :name, age,
@@ -194,13 +315,35 @@ func Segment(segment string) Matchable {
 	}
 
 	id, rx, b := YankSpecial(segment)
+	expr := rx
+
+	var constraint *Constraint
+	var args []string
+
+	if b {
+		c, cargs, ok, err := ParseConstraint(rx)
+		if err != nil {
+			panic(err)
+		}
+
+		if ok {
+			constraint = &c
+			args = cargs
+			rx = c.Pattern
+		}
+	}
+
 	mrk := regexp.MustCompile(rx)
 
 	sm := SegmentMatcher{
-		Regexp:   mrk,
-		original: id,
-		param:    b,
-		hashed:   hashed,
+		Regexp:     mrk,
+		full:       regexp.MustCompile("^(?:" + rx + ")$"),
+		expr:       expr,
+		constraint: constraint,
+		args:       args,
+		original:   id,
+		param:      b,
+		hashed:     hashed,
 	}
 
 	return &sm
@@ -221,9 +364,36 @@ func (s *SegmentMatcher) Segment() string {
 	return s.original
 }
 
-// Validate validates the value against the matcher.
+// Expr returns the regexp or constraint expression of the segment as written
+// in the pattern.
+func (s *SegmentMatcher) Expr() string {
+	return s.expr
+}
+
+// Validate validates the value against the matcher. Segments using a named
+// Constraint must fully match the constraint and pass its checks.
 func (s *SegmentMatcher) Validate(m string) bool {
+	if s.constraint != nil {
+		return s.Check(m) == nil
+	}
+
 	return s.MatchString(m)
 }
 
+// Check returns an error if the giving value does not fully match the segment
+// and pass the checks of its Constraint.
+func (s *SegmentMatcher) Check(m string) error {
+	if !s.full.MatchString(m) {
+		return fmt.Errorf("value %q does not match %q", m, s.expr)
+	}
+
+	if s.constraint != nil && s.constraint.Check != nil {
+		if err := s.constraint.Check(m, s.args); err != nil {
+			return fmt.Errorf("value %q does not satisfy %q: %s", m, s.expr, err)
+		}
+	}
+
+	return nil
+}
+
 //==============================================================================
@@ -1,7 +1,9 @@
 package pattern_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/influx6/faux/pattern"
 )
@@ -149,3 +151,236 @@ func TestRegExpPattern(t *testing.T) {
 		t.Fatalf("incorrect pattern: %+s %t", param, state)
 	}
 }
+
+func TestBuild(t *testing.T) {
+	r := pattern.New(`/name/{id:[\d+]}/log/:date`)
+
+	path, err := r.Build(pattern.Params{"id": "12", "date": "today", "page": "2"})
+	if err != nil {
+		t.Fatalf("Failed: Should have built path: %s", err)
+	}
+
+	if path != "/name/12/log/today?page=2" {
+		t.Fatalf("Failed: Should have built path with query: %s", path)
+	}
+
+	if _, _, state := r.Validate(path[:len(path)-len("?page=2")]); !state {
+		t.Fatalf("Failed: Should have built a path matching the pattern: %s", path)
+	}
+
+	if _, err := r.Build(pattern.Params{"id": "1a", "date": "today"}); err == nil {
+		t.Fatalf("Failed: Should have rejected value not matching segment regexp")
+	}
+
+	if _, err := r.Build(pattern.Params{"id": "12"}); err == nil {
+		t.Fatalf("Failed: Should have rejected missing param")
+	}
+
+	t.Logf("Passed: Should have built path: %s", path)
+}
+
+func TestBuildHashed(t *testing.T) {
+	r := pattern.New(`/github.com/influx6/examples#views`)
+
+	path, err := r.Build(nil)
+	if err != nil || path != "/github.com/influx6/examples#views" {
+		t.Fatalf("Failed: Should have built hashed path: %s %v", path, err)
+	}
+
+	r = pattern.New(`/colors/*`)
+
+	path, err = r.Build(pattern.Params{pattern.RestKey: "red/dark", pattern.FragmentKey: "top"})
+	if err != nil || path != "/colors/red/dark#top" {
+		t.Fatalf("Failed: Should have built endless path with fragment: %s %v", path, err)
+	}
+
+	t.Logf("Passed: Should have built hashed and endless paths")
+}
+
+func TestRouterURL(t *testing.T) {
+	r := pattern.NewRouter()
+	r.HandleNamed("user.logs", "GET", `/users/:name/logs/{day:[\d+]}`, nil)
+
+	path, err := r.URL("user.logs", pattern.Params{"name": "bob", "day": "20"})
+	if err != nil || path != "/users/bob/logs/20" {
+		t.Fatalf("Failed: Should have built named route: %s %v", path, err)
+	}
+
+	if _, err := r.URL("missing", nil); err == nil {
+		t.Fatalf("Failed: Should have failed for unknown route name")
+	}
+
+	t.Logf("Passed: Should have built named route: %s", path)
+}
+
+func TestConstraints(t *testing.T) {
+	r := pattern.New(`/posts/{id:int}/{slug:slug}/{day:date}/{page:int(1,100)}`)
+
+	params, _, state := r.Validate(`/posts/20/hello-world/2017-03-04/5`)
+	if !state {
+		t.Fatalf("Failed: Should have matched typed segments: %#v", params)
+	}
+
+	for _, path := range []string{
+		`/posts/2a/hello-world/2017-03-04/5`,
+		`/posts/20/Hello_World/2017-03-04/5`,
+		`/posts/20/hello-world/2017-13-40/5`,
+		`/posts/20/hello-world/2017-03-04/500`,
+	} {
+		if _, _, state := r.Validate(path); state {
+			t.Fatalf("Failed: Should have rejected path: %s", path)
+		}
+	}
+
+	var post struct {
+		ID   int64     `param:"id"`
+		Slug string    `param:"slug"`
+		Day  time.Time `param:"day"`
+		Page *uint8
+	}
+
+	if err := pattern.Decode(params, &post); err != nil {
+		t.Fatalf("Failed: Should have decoded params: %s", err)
+	}
+
+	if post.ID != 20 || post.Slug != "hello-world" || post.Day.Day() != 4 || post.Page == nil || *post.Page != 5 {
+		t.Fatalf("Failed: Should have decoded typed params: %#v", post)
+	}
+
+	t.Logf("Passed: Should have matched and decoded typed segments: %#v", post)
+}
+
+func TestUnknownConstraint(t *testing.T) {
+	r := pattern.New(`/p/{id:@int}/{name:\w+}`)
+
+	if _, _, state := r.Validate(`/p/12/bob`); !state {
+		t.Fatalf("Failed: Should have matched prefixed constraint and regexp")
+	}
+
+	if _, err := pattern.Compile(`/p/{id:@itn}`); err == nil {
+		t.Fatalf("Failed: Should have failed to compile unknown prefixed constraint")
+	}
+
+	issues := pattern.Analyze(`/p/{id:@itn}`)
+	if len(issues) != 1 || issues[0].Kind != pattern.IssueInvalid {
+		t.Fatalf("Failed: Should have reported unknown prefixed constraint:\n%s", pattern.FormatIssues(issues))
+	}
+
+	t.Logf("Passed: Should have reported unknown prefixed constraint")
+}
+
+func TestCustomConstraint(t *testing.T) {
+	err := pattern.RegisterConstraint("color", pattern.Constraint{Pattern: `red|green|blue`})
+	if err != nil {
+		t.Fatalf("Failed: Should have registered constraint: %s", err)
+	}
+
+	r := pattern.New(`/colors/{name:@color}`)
+
+	if _, _, state := r.Validate(`/colors/green`); !state {
+		t.Fatalf("Failed: Should have matched custom constraint")
+	}
+
+	if _, _, state := r.Validate(`/colors/greenish`); state {
+		t.Fatalf("Failed: Should have rejected value outside custom constraint")
+	}
+
+	var target struct{ Name int }
+	if err := pattern.Decode(pattern.Params{"name": "green"}, &target); err == nil {
+		t.Fatalf("Failed: Should have failed decoding string into int field")
+	}
+
+	t.Logf("Passed: Should have matched custom constraint")
+}
+
+func TestAnalyze(t *testing.T) {
+	issues := pattern.Analyze(
+		`/users/:id`,
+		`/users/{name:\w+}`,
+		`/users/:uid`,
+		`/users/{id:[\d+]}/files/{id:[\d+]}`,
+		`/posts/{id:(\d+}`,
+		`/admin/*`,
+		`/admin/stats`,
+		`/docs/{id:int}`,
+		`/docs/{slug:slug}`,
+		`/docs/{day:date}`,
+	)
+
+	kinds := make(map[pattern.IssueKind][]pattern.Issue)
+	for _, issue := range issues {
+		kinds[issue.Kind] = append(kinds[issue.Kind], issue)
+	}
+
+	if len(kinds[pattern.IssueInvalid]) != 1 || kinds[pattern.IssueInvalid][0].Pattern != `/posts/{id:(\d+}` {
+		t.Fatalf("Failed: Should have reported invalid pattern:\n%s", pattern.FormatIssues(issues))
+	}
+
+	if len(kinds[pattern.IssueDuplicateParam]) != 1 {
+		t.Fatalf("Failed: Should have reported duplicate param:\n%s", pattern.FormatIssues(issues))
+	}
+
+	if len(kinds[pattern.IssueUnreachable]) != 1 || kinds[pattern.IssueUnreachable][0].Pattern != `/users/:uid` {
+		t.Fatalf("Failed: Should have reported unreachable pattern:\n%s", pattern.FormatIssues(issues))
+	}
+
+	if len(kinds[pattern.IssueAmbiguous]) != 2 {
+		t.Fatalf("Failed: Should have reported ambiguous docs patterns:\n%s", pattern.FormatIssues(issues))
+	}
+
+	var endless bool
+	for _, issue := range kinds[pattern.IssueOverlap] {
+		if issue.Pattern == `/admin/stats` && issue.Other == `/admin/*` {
+			endless = true
+		}
+	}
+
+	if !endless || len(kinds[pattern.IssueOverlap]) != 3 {
+		t.Fatalf("Failed: Should have reported overlaps:\n%s", pattern.FormatIssues(issues))
+	}
+
+	t.Logf("Passed: Should have analyzed patterns:\n%s", pattern.FormatIssues(issues))
+}
+
+func TestAnalyzeRoutes(t *testing.T) {
+	r := pattern.NewRouter()
+	r.Handle("GET", `/users/:id`, nil)
+	r.Handle("POST", `/users/:id`, nil)
+	r.Handle("", `/admin/*`, nil)
+
+	var routes []pattern.Route
+	for _, route := range r.Routes() {
+		routes = append(routes, *route)
+	}
+
+	routes = append(routes,
+		pattern.Route{Method: "get", Pattern: `/users/:uid`},
+		pattern.Route{Method: "DELETE", Pattern: `/admin/stats`},
+		pattern.Route{Pattern: `/users/:name`},
+	)
+
+	issues := pattern.AnalyzeRoutes(routes...)
+
+	found := make(map[string]bool)
+	for _, issue := range issues {
+		found[fmt.Sprintf("%s %s %s %s", issue.Kind, issue.Method, issue.OtherMethod, issue.Other)] = true
+	}
+
+	for _, want := range []string{
+		"unreachable GET GET /users/:id",
+		"overlap DELETE  /admin/*",
+		"overlap  GET /users/:id",
+		"overlap  POST /users/:id",
+		"overlap  GET /users/:uid",
+	} {
+		if !found[want] {
+			t.Fatalf("Failed: Should have reported %q:\n%s", want, pattern.FormatIssues(issues))
+		}
+	}
+
+	if len(issues) != 5 {
+		t.Fatalf("Failed: Should have reported issues between matching methods only:\n%s", pattern.FormatIssues(issues))
+	}
+
+	t.Logf("Passed: Should have analyzed routes by method:\n%s", pattern.FormatIssues(issues))
+}
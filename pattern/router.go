@@ -0,0 +1,377 @@
+package pattern
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AnyMethod defines the method used to register a handler for all methods.
+const AnyMethod = "*"
+
+// Route defines a single pattern registered with a Router for a method.
+type Route struct {
+	Name    string
+	Method  string
+	Pattern string
+	Handler http.Handler
+	Matcher URIMatcher
+
+	endless bool
+}
+
+// edge defines a parameter segment leading to a child node.
+type edge struct {
+	raw     string
+	segment Matchable
+	child   *node
+}
+
+// node defines a single segment level within the routing trie.
+type node struct {
+	static  map[string]*node
+	regexes []*edge
+	picks   []*edge
+	routes  map[string]*Route
+	endless map[string]*Route
+}
+
+func newNode() *node {
+	return &node{
+		static:  make(map[string]*node),
+		routes:  make(map[string]*Route),
+		endless: make(map[string]*Route),
+	}
+}
+
+// paramsKey defines the key used to store Params in a request context.
+type paramsKey struct{}
+
+// ParamsFrom returns the Params stored in the giving context by a Router.
+func ParamsFrom(ctx context.Context) Params {
+	params, _ := ctx.Value(paramsKey{}).(Params)
+	return params
+}
+
+// WithParams returns a new context which carries the giving Params.
+func WithParams(ctx context.Context, params Params) context.Context {
+	return context.WithValue(ctx, paramsKey{}, params)
+}
+
+//==============================================================================
+
+// Router defines a http.Handler which compiles many patterns into a trie of
+// segments and dispatches requests by method. At every segment, static
+// segments are tried before restricted parameters ({id:[\d+]}), which are
+// tried before plain parameters (:id) and endless patterns (/*), following
+// the ordering of CheckPriority.
+type Router struct {
+	// NotFound is called when no pattern matches, http.NotFound by default.
+	NotFound http.Handler
+
+	// MethodNotAllowed is called when a pattern matches but has no handler
+	// for the request method. A 405 response is written by default.
+	MethodNotAllowed http.Handler
+
+	rl     sync.RWMutex
+	root   *node
+	routes []*Route
+	named  map[string]*Route
+}
+
+// NewRouter returns a new instance of a Router.
+func NewRouter() *Router {
+	return &Router{
+		root:  newNode(),
+		named: make(map[string]*Route),
+	}
+}
+
+// HandleFunc registers the giving function for the method and pattern.
+func (r *Router) HandleFunc(method string, pattern string, fn func(http.ResponseWriter, *http.Request)) {
+	r.Handle(method, pattern, http.HandlerFunc(fn))
+}
+
+// Handle registers the giving handler for the method and pattern. An empty
+// method or AnyMethod registers the handler for all methods. Handle panics if
+// the method and pattern are already registered.
+func (r *Router) Handle(method string, pattern string, handler http.Handler) {
+	r.HandleNamed("", method, pattern, handler)
+}
+
+// HandleNamed registers the giving handler like Handle and makes the route
+// available under the giving name for Lookup and URL. HandleNamed panics if
+// the name is already registered.
+func (r *Router) HandleNamed(name string, method string, pattern string, handler http.Handler) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = AnyMethod
+	}
+
+	matcher := New(pattern)
+
+	route := &Route{
+		Name:    name,
+		Method:  method,
+		Pattern: matcher.Pattern(),
+		Handler: handler,
+		Matcher: matcher,
+		endless: IsEndless(matcher.Pattern()),
+	}
+
+	r.rl.Lock()
+	defer r.rl.Unlock()
+
+	if existing, ok := r.named[name]; ok && name != "" {
+		panic(fmt.Sprintf("pattern: route name %q already registered for %s", name, existing.Pattern))
+	}
+
+	current := r.root
+
+	for _, segment := range SegmentList(matcher.Pattern()) {
+		current = current.child(segment)
+	}
+
+	target := current.routes
+	if route.endless {
+		target = current.endless
+	}
+
+	if existing, ok := target[method]; ok {
+		panic(fmt.Sprintf("pattern: %s %s conflicts with registered %s", method, pattern, existing.Pattern))
+	}
+
+	target[method] = route
+	r.routes = append(r.routes, route)
+
+	if name != "" {
+		r.named[name] = route
+	}
+}
+
+// Lookup returns the route registered with the giving name.
+func (r *Router) Lookup(name string) (*Route, bool) {
+	r.rl.RLock()
+	defer r.rl.RUnlock()
+
+	route, ok := r.named[name]
+	return route, ok
+}
+
+// URL returns the path built from the route registered with the giving name
+// and the provided Params.
+func (r *Router) URL(name string, params Params) (string, error) {
+	route, ok := r.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("pattern: no route named %q", name)
+	}
+
+	return route.Matcher.Build(params)
+}
+
+// Routes returns all registered routes in registration order.
+func (r *Router) Routes() []*Route {
+	r.rl.RLock()
+	defer r.rl.RUnlock()
+
+	routes := make([]*Route, len(r.routes))
+	copy(routes, r.routes)
+	return routes
+}
+
+// Match returns the Route registered for the method which matches the giving
+// path, with its Params and the remaining path for endless patterns.
+func (r *Router) Match(method string, path string) (*Route, Params, string, bool) {
+	r.rl.RLock()
+	defer r.rl.RUnlock()
+
+	src := trimSegments(splitPattern(cleanPath(stripAndClean(addSlash(path)))))
+
+	var pairs []string
+	route, ok := r.root.find(strings.ToUpper(method), src, 0, &pairs)
+	if !ok {
+		return nil, nil, "", false
+	}
+
+	params := make(Params, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[pairs[i]] = pairs[i+1]
+	}
+
+	var rem string
+	if route.endless {
+		_, rem, _ = route.Matcher.Validate(path)
+	}
+
+	return route, params, rem, true
+}
+
+// Allowed returns the methods registered for patterns matching the path.
+func (r *Router) Allowed(path string) []string {
+	r.rl.RLock()
+	defer r.rl.RUnlock()
+
+	src := trimSegments(splitPattern(cleanPath(stripAndClean(addSlash(path)))))
+
+	seen := make(map[string]bool)
+	r.root.methods(src, 0, seen)
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+
+	sort.Strings(methods)
+	return methods
+}
+
+// ServeHTTP dispatches the request to the handler of the matching route,
+// storing the matched Params in the request context.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route, params, _, ok := r.Match(req.Method, req.URL.Path)
+	if ok {
+		route.Handler.ServeHTTP(w, req.WithContext(WithParams(req.Context(), params)))
+		return
+	}
+
+	if allowed := r.Allowed(req.URL.Path); len(allowed) > 0 {
+		if r.MethodNotAllowed != nil {
+			r.MethodNotAllowed.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.NotFound != nil {
+		r.NotFound.ServeHTTP(w, req)
+		return
+	}
+
+	http.NotFound(w, req)
+}
+
+//==============================================================================
+
+// child returns the child node for the giving segment, creating it if needed.
+func (n *node) child(segment Matchable) *node {
+	if !segment.IsParam() {
+		next, ok := n.static[segment.Segment()]
+		if !ok {
+			next = newNode()
+			n.static[segment.Segment()] = next
+		}
+
+		return next
+	}
+
+	raw := segment.Segment() + ":" + segment.(*SegmentMatcher).Expr()
+
+	edges := &n.picks
+	if segment.(*SegmentMatcher).Expr() != anyvalue {
+		edges = &n.regexes
+	}
+
+	for _, item := range *edges {
+		if item.raw == raw {
+			return item.child
+		}
+	}
+
+	next := &edge{raw: raw, segment: segment, child: newNode()}
+	*edges = append(*edges, next)
+
+	return next.child
+}
+
+// route returns the route registered for the method or for AnyMethod.
+func route(routes map[string]*Route, method string) (*Route, bool) {
+	if rt, ok := routes[method]; ok {
+		return rt, true
+	}
+
+	rt, ok := routes[AnyMethod]
+	return rt, ok
+}
+
+// find walks the trie for the giving segments, backtracking to lower
+// priority branches when a branch does not lead to a route for the method.
+func (n *node) find(method string, src []string, depth int, pairs *[]string) (*Route, bool) {
+	if depth == len(src) {
+		if rt, ok := route(n.routes, method); ok {
+			return rt, true
+		}
+
+		return route(n.endless, method)
+	}
+
+	segment := src[depth]
+
+	if next, ok := n.static[segment]; ok {
+		if rt, ok := next.find(method, src, depth+1, pairs); ok {
+			return rt, true
+		}
+	}
+
+	for _, group := range [][]*edge{n.regexes, n.picks} {
+		for _, item := range group {
+			if !item.segment.Validate(segment) {
+				continue
+			}
+
+			mark := len(*pairs)
+			*pairs = append(*pairs, item.segment.Segment(), segment)
+
+			if rt, ok := item.child.find(method, src, depth+1, pairs); ok {
+				return rt, true
+			}
+
+			*pairs = (*pairs)[:mark]
+		}
+	}
+
+	return route(n.endless, method)
+}
+
+// methods collects the methods of all routes matching the giving segments.
+func (n *node) methods(src []string, depth int, seen map[string]bool) {
+	for method := range n.endless {
+		seen[method] = true
+	}
+
+	if depth == len(src) {
+		for method := range n.routes {
+			seen[method] = true
+		}
+
+		return
+	}
+
+	segment := src[depth]
+
+	if next, ok := n.static[segment]; ok {
+		next.methods(src, depth+1, seen)
+	}
+
+	for _, group := range [][]*edge{n.regexes, n.picks} {
+		for _, item := range group {
+			if item.segment.Validate(segment) {
+				item.child.methods(src, depth+1, seen)
+			}
+		}
+	}
+}
+
+// trimSegments removes the empty trailing segment left by a trailing slash.
+func trimSegments(segments []string) []string {
+	for len(segments) > 1 && segments[len(segments)-1] == "" {
+		segments = segments[:len(segments)-1]
+	}
+
+	return segments
+}
@@ -0,0 +1,75 @@
+package pattern_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influx6/faux/pattern"
+)
+
+func TestRouterPriority(t *testing.T) {
+	r := pattern.NewRouter()
+
+	for _, patt := range []string{`/users/:name`, `/users/{id:[\d+]}`, `/users/admin`, `/users/:name/files/*`} {
+		r.Handle("GET", patt, http.NotFoundHandler())
+	}
+
+	route, _, _, ok := r.Match("GET", `/users/admin`)
+	if !ok || route.Pattern != `/users/admin` {
+		t.Fatalf("Failed: Should have matched static pattern for /users/admin: %#v", route)
+	}
+
+	route, params, _, ok := r.Match("GET", `/users/12`)
+	if !ok || route.Pattern != `/users/{id:[\d+]}` || params["id"] != "12" {
+		t.Fatalf("Failed: Should have matched restricted pattern for /users/12: %#v %#v", route, params)
+	}
+
+	route, params, _, ok = r.Match("GET", `/users/bob`)
+	if !ok || route.Pattern != `/users/:name` || params["name"] != "bob" {
+		t.Fatalf("Failed: Should have matched picker pattern for /users/bob: %#v %#v", route, params)
+	}
+
+	route, params, rem, ok := r.Match("GET", `/users/bob/files/docs/a.txt`)
+	if !ok || route.Pattern != `/users/:name/files/*` || params["name"] != "bob" || rem != "/docs/a.txt" {
+		t.Fatalf("Failed: Should have matched endless pattern: %#v %#v %q", route, params, rem)
+	}
+
+	if _, _, _, ok := r.Match("GET", `/accounts/bob`); ok {
+		t.Fatalf("Failed: Should not have matched unknown path")
+	}
+
+	t.Logf("Passed: Should have matched routes by priority")
+}
+
+func TestRouterServeHTTP(t *testing.T) {
+	r := pattern.NewRouter()
+
+	r.HandleFunc("GET", `/name/{id:[\d+]}/log/:date`, func(w http.ResponseWriter, req *http.Request) {
+		params := pattern.ParamsFrom(req.Context())
+		w.Write([]byte(params["id"] + "@" + params["date"]))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/name/20/log/today", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "20@today" {
+		t.Fatalf("Failed: Should have dispatched with params: %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", "/name/20/log/today", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET" {
+		t.Fatalf("Failed: Should have responded with method not allowed: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/name/20", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Failed: Should have responded with not found: %d", rec.Code)
+	}
+
+	t.Logf("Passed: Should have dispatched requests by method")
+}
@@ -0,0 +1,230 @@
+package reflection
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// CopyOptions defines the settings used by DeepCopyWith.
+type CopyOptions struct {
+	// Unexported sets unexported struct fields to be deep copied, else they
+	// are copied by value, sharing what they point to with the original.
+	Unexported bool
+}
+
+// DeepCopy returns a deep copy of the giving value, where pointers, slices,
+// maps and interfaces are copied recursively and cycles are preserved within
+// the copy. Values implementing a Clone method which returns their own type
+// are copied by calling it, so such a method must not call DeepCopy on its own
+// receiver. Unexported struct fields are copied by value, so values such as
+// time.Time are kept intact, see DeepCopyWith. Functions and channels are
+// shared by the copy.
+func DeepCopy(elem interface{}) interface{} {
+	return DeepCopyWith(elem, CopyOptions{})
+}
+
+// DeepCopyWith returns a deep copy of the giving value like DeepCopy using the
+// provided options.
+func DeepCopyWith(elem interface{}, ops CopyOptions) interface{} {
+	if elem == nil {
+		return nil
+	}
+
+	// Use an addressable source so Clone methods with pointer receivers and
+	// unexported fields can be reached.
+	src := reflect.New(reflect.TypeOf(elem)).Elem()
+	src.Set(reflect.ValueOf(elem))
+
+	cp := copier{ops: ops, seen: make(map[copyKey]reflect.Value)}
+
+	dst := reflect.New(src.Type()).Elem()
+	cp.copy(dst, src)
+
+	return dst.Interface()
+}
+
+// copyKey defines the key of a copied pointer, map or slice, where the type is
+// needed as a struct and its first field share an address.
+type copyKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// copier defines the state of a single DeepCopy call.
+type copier struct {
+	ops  CopyOptions
+	seen map[copyKey]reflect.Value
+}
+
+// copy sets a deep copy of src into dst, which must be settable.
+func (c *copier) copy(dst, src reflect.Value) {
+	if !src.IsValid() {
+		return
+	}
+
+	if cloned, ok := clone(src); ok {
+		dst.Set(cloned)
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+
+		item := reflect.New(src.Type().Elem())
+		c.seen[key] = item
+
+		c.copy(item.Elem(), src.Elem())
+		dst.Set(item)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+
+		item := reflect.New(src.Elem().Type()).Elem()
+		c.copy(item, src.Elem())
+		dst.Set(item)
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+
+		items := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.seen[key] = items
+
+		for _, mkey := range src.MapKeys() {
+			kcopy := reflect.New(mkey.Type()).Elem()
+			c.copy(kcopy, mkey)
+
+			vcopy := reflect.New(src.Type().Elem()).Elem()
+			c.copy(vcopy, src.MapIndex(mkey))
+
+			items.SetMapIndex(kcopy, vcopy)
+		}
+
+		dst.Set(items)
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+
+		key := copyKey{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+
+		items := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		c.seen[key] = items
+
+		for i := 0; i < src.Len(); i++ {
+			c.copy(items.Index(i), src.Index(i))
+		}
+
+		dst.Set(items)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Struct:
+		c.copyStruct(dst, src)
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// copyStruct copies the fields of the src struct into dst.
+func (c *copier) copyStruct(dst, src reflect.Value) {
+	// Unexported fields can only be read through an addressable value.
+	if c.ops.Unexported && !src.CanAddr() {
+		addr := reflect.New(src.Type()).Elem()
+		addr.Set(src)
+		src = addr
+	}
+
+	// Copy the struct by value first, so unexported fields which are not deep
+	// copied keep their state, such as the wall clock of a time.Time.
+	if !c.ops.Unexported && dst.CanSet() {
+		dst.Set(src)
+	}
+
+	tl := src.Type()
+
+	for i := 0; i < tl.NumField(); i++ {
+		sfl, dfl := src.Field(i), dst.Field(i)
+
+		if field := tl.Field(i); field.PkgPath != "" {
+			// Exported fields of embedded structs are still copied.
+			if !c.ops.Unexported && field.Anonymous && field.Type.Kind() == reflect.Struct {
+				c.copyStruct(dfl, sfl)
+				continue
+			}
+
+			if !c.ops.Unexported {
+				continue
+			}
+
+			sfl = reflect.NewAt(sfl.Type(), unsafe.Pointer(sfl.UnsafeAddr())).Elem()
+			dfl = reflect.NewAt(dfl.Type(), unsafe.Pointer(dfl.UnsafeAddr())).Elem()
+		}
+
+		c.copy(dfl, sfl)
+	}
+}
+
+// clone returns the result of the Clone method of the value if it has one
+// without arguments which returns its own type or a pointer to it.
+func clone(src reflect.Value) (reflect.Value, bool) {
+	if src.Kind() == reflect.Ptr && src.IsNil() {
+		return reflect.Value{}, false
+	}
+
+	method := src.MethodByName("Clone")
+	if !method.IsValid() && src.CanAddr() {
+		method = src.Addr().MethodByName("Clone")
+	}
+
+	if !method.IsValid() {
+		return reflect.Value{}, false
+	}
+
+	mt := method.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+
+	switch out := mt.Out(0); {
+	case out == src.Type():
+		return method.Call(nil)[0], true
+	case out.Kind() == reflect.Ptr && out.Elem() == src.Type():
+		res := method.Call(nil)[0]
+		if res.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		return res.Elem(), true
+	}
+
+	return reflect.Value{}, false
+}
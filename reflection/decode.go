@@ -0,0 +1,142 @@
+package reflection
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DecodeConfig decodes the raw JSON config, or one in a format added with
+// RegisterRawDecoder such as YAML, into the target, which must be a pointer.
+// The config is first validated against the schema of the target type with its
+// defaults applied, see SchemaOf, then decoded with encoding/json and validated
+// with Validate if the target is a struct. It returns ValidationErrors if the
+// config is invalid.
+func DecodeConfig(raw []byte, target interface{}) error {
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Ptr || tv.IsNil() {
+		return fmt.Errorf("reflection: DecodeConfig requires a non-nil pointer, not %T", target)
+	}
+
+	schema, err := SchemaOf(tv.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	value, err := DecodeRaw(raw)
+	if err != nil {
+		return err
+	}
+
+	value = schema.ApplyDefaults(value)
+
+	if err := schema.Validate(value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return err
+	}
+
+	if IsStruct(target) {
+		return Validate(target)
+	}
+
+	return nil
+}
+
+// RawDecoder defines a function which decodes a raw config in a format other
+// than JSON into the types returned by DecodeRaw.
+type RawDecoder func(raw []byte) (interface{}, error)
+
+var rawDecoders = struct {
+	rl    sync.RWMutex
+	items map[string]RawDecoder
+}{
+	items: make(map[string]RawDecoder),
+}
+
+// RegisterRawDecoder adds the decoder used by DecodeRaw for raw configs which
+// are not JSON under the giving format name, replacing any decoder with the
+// same name. The reflection/yamlconfig package registers a YAML decoder when
+// imported.
+func RegisterRawDecoder(format string, decoder RawDecoder) {
+	rawDecoders.rl.Lock()
+	defer rawDecoders.rl.Unlock()
+
+	rawDecoders.items[format] = decoder
+}
+
+// DecodeRaw decodes the raw JSON into a value made of the types used by
+// encoding/json, i.e map[string]interface{}, []interface{}, string, bool and
+// nil, where numbers are kept exact as json.Number. Raw configs which are not
+// JSON are decoded by the decoders added with RegisterRawDecoder, tried in
+// order of their format names. Empty input decodes to nil.
+func DecodeRaw(raw []byte) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	if json.Valid(raw) {
+		var value interface{}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	}
+
+	rawDecoders.rl.RLock()
+	formats := make([]string, 0, len(rawDecoders.items))
+	for format := range rawDecoders.items {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	decoders := make([]RawDecoder, len(formats))
+	for index, format := range formats {
+		decoders[index] = rawDecoders.items[format]
+	}
+	rawDecoders.rl.RUnlock()
+
+	var failures []string
+	for index, decoder := range decoders {
+		value, err := decoder(raw)
+		if err == nil {
+			return value, nil
+		}
+
+		failures = append(failures, fmt.Sprintf("%s: %s", formats[index], err))
+	}
+
+	if len(failures) == 0 {
+		return nil, fmt.Errorf("reflection: config is not valid JSON")
+	}
+
+	return nil, fmt.Errorf("reflection: config is neither valid JSON nor %s", strings.Join(failures, ", "))
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(items map[string]interface{}) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,250 @@
+package reflection
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind defines the kind of a Change found by Diff.
+type ChangeKind string
+
+// contains the kinds of changes reported by Diff.
+const (
+	// Added reports a slice item or map key only found in the new value.
+	Added ChangeKind = "added"
+
+	// Removed reports a slice item or map key only found in the old value.
+	Removed ChangeKind = "removed"
+
+	// Modified reports a value which differs between the old and new value.
+	Modified ChangeKind = "modified"
+)
+
+// Change defines a single difference between two values at a giving path,
+// which uses the names of json tags where available, e.g users[0].email.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// String returns a readable form of the change.
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %#v", path, c.New)
+	case Removed:
+		return fmt.Sprintf("- %s: %#v", path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %#v => %#v", path, c.Old, c.New)
+	}
+}
+
+// Changes defines the list of changes returned by Diff.
+type Changes []Change
+
+// String returns a report of all changes with one change per line.
+func (c Changes) String() string {
+	var bu bytes.Buffer
+
+	for _, change := range c {
+		bu.WriteString(change.String())
+		bu.WriteByte('\n')
+	}
+
+	return bu.String()
+}
+
+// Diff returns the changes between the giving values, walking through
+// exported struct fields, pointers, slices, arrays, maps and interfaces. Values
+// with an Equal method, such as time.Time, are compared with it. Added and
+// removed slice items and map keys are reported as such, all other
+// differences as modified.
+func Diff(a, b interface{}) Changes {
+	df := differ{seen: make(map[diffKey]bool)}
+	df.diff("", reflect.ValueOf(a), reflect.ValueOf(b))
+	return df.changes
+}
+
+// diffKey defines a pair of compared pointers, used to stop at cycles.
+type diffKey struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// differ defines the state of a single Diff call.
+type differ struct {
+	changes Changes
+	seen    map[diffKey]bool
+}
+
+func (d *differ) add(kind ChangeKind, path string, a, b reflect.Value) {
+	d.changes = append(d.changes, Change{
+		Kind: kind,
+		Path: path,
+		Old:  valueOf(a),
+		New:  valueOf(b),
+	})
+}
+
+// diff compares the giving values at the path.
+func (d *differ) diff(path string, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(Modified, path, a, b)
+		}
+
+		return
+	}
+
+	if a.Type() != b.Type() {
+		d.add(Modified, path, a, b)
+		return
+	}
+
+	if equal, ok := equalMethod(a, b); ok {
+		if !equal {
+			d.add(Modified, path, a, b)
+		}
+
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(Modified, path, a, b)
+			}
+
+			return
+		}
+
+		if a.Kind() == reflect.Ptr {
+			key := diffKey{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+			if d.seen[key] {
+				return
+			}
+
+			d.seen[key] = true
+		}
+
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Struct:
+		tl := a.Type()
+
+		for i := 0; i < tl.NumField(); i++ {
+			field := tl.Field(i)
+
+			if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+				continue
+			}
+
+			fieldPath := path
+			if !field.Anonymous || field.Tag.Get("json") != "" {
+				fieldPath = joinPath(path, jsonName(field))
+			}
+
+			d.diff(fieldPath, a.Field(i), b.Field(i))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+			switch {
+			case i >= b.Len():
+				d.add(Removed, itemPath, a.Index(i), reflect.Value{})
+			case i >= a.Len():
+				d.add(Added, itemPath, reflect.Value{}, b.Index(i))
+			default:
+				d.diff(itemPath, a.Index(i), b.Index(i))
+			}
+		}
+
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, key := range a.MapKeys() {
+			keys[fmt.Sprintf("%v", key.Interface())] = key
+		}
+
+		for _, key := range b.MapKeys() {
+			keys[fmt.Sprintf("%v", key.Interface())] = key
+		}
+
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			key := keys[name]
+			itemPath := joinPath(path, name)
+
+			av, bv := a.MapIndex(key), b.MapIndex(key)
+
+			switch {
+			case !bv.IsValid():
+				d.add(Removed, itemPath, av, reflect.Value{})
+			case !av.IsValid():
+				d.add(Added, itemPath, reflect.Value{}, bv)
+			default:
+				d.diff(itemPath, av, bv)
+			}
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			d.add(Modified, path, a, b)
+		}
+
+	default:
+		if !reflect.DeepEqual(valueOf(a), valueOf(b)) {
+			d.add(Modified, path, a, b)
+		}
+	}
+}
+
+// equalMethod compares the values with their Equal method if they have one
+// which takes their own type and returns a bool.
+func equalMethod(a, b reflect.Value) (bool, bool) {
+	if !a.CanInterface() || !b.CanInterface() {
+		return false, false
+	}
+
+	method := a.MethodByName("Equal")
+	if !method.IsValid() {
+		return false, false
+	}
+
+	mt := method.Type()
+	if mt.NumIn() != 1 || mt.NumOut() != 1 || mt.In(0) != a.Type() || mt.Out(0).Kind() != reflect.Bool {
+		return false, false
+	}
+
+	if a.Kind() == reflect.Ptr && (a.IsNil() || b.IsNil()) {
+		return false, false
+	}
+
+	return method.Call([]reflect.Value{b})[0].Bool(), true
+}
+
+// valueOf returns the interface of the value or nil if it is not valid.
+func valueOf(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,669 @@
+package reflection
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathError defines an error which occured for the value at a giving path
+// within a struct, e.g address.street or items[2].name.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+// Error returns the error message with the path of the value.
+func (p *PathError) Error() string {
+	return fmt.Sprintf("field %s: %s", p.Path, p.Err)
+}
+
+// tagOptions defines the options provided after the name of a tag value, e.g
+// the omitempty in `json:"name,omitempty"`.
+type tagOptions []string
+
+// Has returns true/false if the giving option exists.
+func (t tagOptions) Has(option string) bool {
+	for _, item := range t {
+		if item == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTag returns the name and options of a tag value.
+func parseTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+	for index, part := range parts {
+		parts[index] = strings.TrimSpace(part)
+	}
+
+	return parts[0], tagOptions(parts[1:])
+}
+
+// mapField defines a field of a struct with its parsed tag.
+type mapField struct {
+	Field
+	name   string
+	opts   tagOptions
+	inline bool
+}
+
+// mapFields returns the exported fields of the struct type with their parsed
+// tags. Embedded structs without a tag name and fields with the inline option
+// are marked as inline.
+func mapFields(tl reflect.Type, tag string, allowNaturalNames bool) ([]mapField, error) {
+	fields, err := GetTagFields(reflect.New(tl).Interface(), tag, allowNaturalNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []mapField
+
+	for _, field := range fields {
+		sf := tl.Field(field.Index)
+
+		name, opts := parseTag(field.Tag)
+
+		inline := isStructType(sf.Type) && (opts.Has("inline") || (sf.Anonymous && sf.Tag.Get(tag) == ""))
+
+		// Skip unexported fields unless they are inlined structs whose
+		// exported fields are promoted.
+		if sf.PkgPath != "" && !inline {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		items = append(items, mapField{
+			Field:  field,
+			name:   name,
+			opts:   opts,
+			inline: inline,
+		})
+	}
+
+	return items, nil
+}
+
+// isStructType returns true/false if the type is a struct or a pointer to one.
+func isStructType(tl reflect.Type) bool {
+	if tl.Kind() == reflect.Ptr {
+		tl = tl.Elem()
+	}
+
+	return tl.Kind() == reflect.Struct
+}
+
+// joinPath returns the path of a field within its parent path.
+func joinPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//==============================================================================
+
+// ToMap returns a map of the giving values from a struct using a provided
+// tag to capture the needed values, it extracts those tags values out into
+// a map. It returns an error if the element is not a struct.
+//
+// Nested structs, pointers, slices and maps are converted recursively, where
+// structs become maps, slices become []interface{} and maps become
+// map[string]interface{}. Structs implementing encoding.TextMarshaler, such as
+// time.Time, are kept as is. The tag options omitempty, inline and string are
+// supported, where embedded structs without a tag name are always inlined.
+func ToMap(tag string, elem interface{}, allowNaturalNames bool) (map[string]interface{}, error) {
+	if !IsStruct(elem) {
+		return nil, ErrNotStruct
+	}
+
+	seen := make(map[uintptr]bool)
+
+	tl := reflect.ValueOf(elem)
+
+	if tl.Kind() == reflect.Ptr {
+		seen[tl.Pointer()] = true
+		tl = tl.Elem()
+	}
+
+	data := make(map[string]interface{})
+
+	count, err := structToMap(tag, tl, allowNaturalNames, "", data, seen)
+	if err != nil {
+		return nil, err
+	}
+
+	// If there exists no field matching the tag return an error.
+	if count == 0 {
+		return nil, errors.New("No Tag Matches")
+	}
+
+	return data, nil
+}
+
+// structToMap adds the fields of the struct value into the map, returning the
+// number of matched fields.
+func structToMap(tag string, tl reflect.Value, allowNaturalNames bool, path string, data map[string]interface{}, seen map[uintptr]bool) (int, error) {
+	fields, err := mapFields(tl.Type(), tag, allowNaturalNames)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	// Loop through  the fields and set the appropriate value as needed.
+	for _, field := range fields {
+		fl := tl.Field(field.Index)
+
+		if field.inline {
+			if fl.Kind() == reflect.Ptr {
+				if fl.IsNil() {
+					continue
+				}
+
+				fl = fl.Elem()
+			}
+
+			total, err := structToMap(tag, fl, allowNaturalNames, path, data, seen)
+			if err != nil {
+				return 0, err
+			}
+
+			count += total
+			continue
+		}
+
+		count++
+
+		if field.opts.Has("omitempty") && isEmptyValue(fl) {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.name)
+
+		value, err := toMapValue(tag, fl, allowNaturalNames, fieldPath, seen)
+		if err != nil {
+			return 0, err
+		}
+
+		if field.opts.Has("string") {
+			value = toStringOption(value)
+		}
+
+		data[field.name] = value
+	}
+
+	return count, nil
+}
+
+// toMapValue returns the map representation of the giving value.
+func toMapValue(tag string, fl reflect.Value, allowNaturalNames bool, path string, seen map[uintptr]bool) (interface{}, error) {
+	switch fl.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+
+	case reflect.Ptr, reflect.Interface:
+		if fl.IsNil() {
+			return nil, nil
+		}
+
+		if fl.Kind() == reflect.Ptr {
+			if seen[fl.Pointer()] {
+				return nil, &PathError{Path: path, Err: errors.New("cycle detected")}
+			}
+
+			seen[fl.Pointer()] = true
+			defer delete(seen, fl.Pointer())
+		}
+
+		return toMapValue(tag, fl.Elem(), allowNaturalNames, path, seen)
+
+	case reflect.Struct:
+		if fl.Type().Implements(textMarshalerType) || reflect.PtrTo(fl.Type()).Implements(textMarshalerType) {
+			return fl.Interface(), nil
+		}
+
+		data := make(map[string]interface{})
+		if _, err := structToMap(tag, fl, allowNaturalNames, path, data, seen); err != nil {
+			return nil, err
+		}
+
+		return data, nil
+
+	case reflect.Slice, reflect.Array:
+		if fl.Kind() == reflect.Slice && fl.IsNil() {
+			return nil, nil
+		}
+
+		// Keep byte slices as is.
+		if fl.Type().Elem().Kind() == reflect.Uint8 {
+			return fl.Interface(), nil
+		}
+
+		items := make([]interface{}, fl.Len())
+		for i := 0; i < fl.Len(); i++ {
+			item, err := toMapValue(tag, fl.Index(i), allowNaturalNames, fmt.Sprintf("%s[%d]", path, i), seen)
+			if err != nil {
+				return nil, err
+			}
+
+			items[i] = item
+		}
+
+		return items, nil
+
+	case reflect.Map:
+		if fl.IsNil() {
+			return nil, nil
+		}
+
+		data := make(map[string]interface{}, fl.Len())
+		for _, key := range fl.MapKeys() {
+			name := fmt.Sprintf("%v", key.Interface())
+
+			item, err := toMapValue(tag, fl.MapIndex(key), allowNaturalNames, joinPath(path, name), seen)
+			if err != nil {
+				return nil, err
+			}
+
+			data[name] = item
+		}
+
+		return data, nil
+
+	default:
+		return fl.Interface(), nil
+	}
+}
+
+// toStringOption returns the string form of scalar values for fields with the
+// string tag option.
+func toStringOption(value interface{}) interface{} {
+	switch item := value.(type) {
+	case nil, string:
+		return value
+	case bool:
+		return strconv.FormatBool(item)
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return fmt.Sprintf("%v", value)
+	}
+
+	return value
+}
+
+// isEmptyValue returns true/false if the value is empty for the omitempty
+// tag option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+//==============================================================================
+
+// MergeMap merges the key names of the provided map into the appropriate field
+// place where the element has the provided tag.
+//
+// Nested maps are merged into struct, pointer and map fields and slices into
+// slice fields, where values are converted to the field types when possible.
+// The tag options inline and string are supported, where embedded structs
+// without a tag name are always inlined. A *PathError naming the field is
+// returned for values which can not be used for a field.
+func MergeMap(tag string, elem interface{}, values map[string]interface{}, allowAll bool) error {
+	if !IsStruct(elem) {
+		return ErrNotStruct
+	}
+
+	tl := reflect.ValueOf(elem)
+
+	if tl.Kind() == reflect.Ptr {
+		tl = tl.Elem()
+	}
+
+	_, err := mergeStruct(tag, tl, values, allowAll, "")
+	return err
+}
+
+// mergeStruct merges the values into the fields of the struct value, returning
+// the number of fields set.
+func mergeStruct(tag string, tl reflect.Value, values map[string]interface{}, allowAll bool, path string) (int, error) {
+	fields, err := mapFields(tl.Type(), tag, allowAll)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	// Loop through  the fields and set the appropriate value as needed.
+	for _, field := range fields {
+		fl := tl.Field(field.Index)
+
+		// Inlined structs are merged in place as the exported fields of
+		// unexported embedded structs can still be set.
+		if field.inline && (fl.Kind() == reflect.Struct || fl.CanSet()) {
+			target := fl
+			if fl.Kind() == reflect.Ptr {
+				target = reflect.New(fl.Type().Elem()).Elem()
+				if !fl.IsNil() {
+					target.Set(fl.Elem())
+				}
+			}
+
+			total, err := mergeStruct(tag, target, values, allowAll, path)
+			if err != nil {
+				return 0, err
+			}
+
+			if fl.Kind() == reflect.Ptr && total > 0 {
+				fl.Set(target.Addr())
+			}
+
+			count += total
+			continue
+		}
+
+		// If we can't set this field, then skip.
+		if !fl.CanSet() {
+			continue
+		}
+
+		item := values[field.name]
+
+		if item == nil {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.name)
+
+		if field.opts.Has("string") {
+			if text, ok := item.(string); ok {
+				if err := setString(fl, text); err != nil {
+					return 0, &PathError{Path: fieldPath, Err: err}
+				}
+
+				count++
+				continue
+			}
+		}
+
+		if err := mergeValue(tag, fl, reflect.ValueOf(item), allowAll, fieldPath); err != nil {
+			return 0, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// mergeValue sets the value into the target, converting it to the type of the
+// target where needed.
+func mergeValue(tag string, target reflect.Value, val reflect.Value, allowAll bool, path string) error {
+	if val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+
+	if !val.IsValid() {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	if val.Type().AssignableTo(target.Type()) {
+		target.Set(val)
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		item := reflect.New(target.Type().Elem())
+		if !target.IsNil() {
+			item.Elem().Set(target.Elem())
+		}
+
+		if err := mergeValue(tag, item.Elem(), val, allowAll, path); err != nil {
+			return err
+		}
+
+		target.Set(item)
+		return nil
+
+	case reflect.Struct:
+		values, ok := val.Interface().(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		_, err := mergeStruct(tag, target, values, allowAll, path)
+		return err
+
+	case reflect.Slice, reflect.Array:
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			break
+		}
+
+		items := target
+		if target.Kind() == reflect.Slice {
+			items = reflect.MakeSlice(target.Type(), val.Len(), val.Len())
+		} else if val.Len() > target.Len() {
+			return &PathError{Path: path, Err: fmt.Errorf("can not set %d items into %s", val.Len(), target.Type())}
+		}
+
+		for i := 0; i < val.Len(); i++ {
+			if err := mergeValue(tag, items.Index(i), val.Index(i), allowAll, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		target.Set(items)
+		return nil
+
+	case reflect.Map:
+		if val.Kind() != reflect.Map {
+			break
+		}
+
+		items := reflect.MakeMapWithSize(target.Type(), val.Len())
+
+		for _, key := range val.MapKeys() {
+			keyPath := joinPath(path, fmt.Sprintf("%v", key.Interface()))
+
+			mkey := reflect.New(target.Type().Key()).Elem()
+			if err := mergeValue(tag, mkey, key, allowAll, keyPath); err != nil {
+				return err
+			}
+
+			mval := reflect.New(target.Type().Elem()).Elem()
+			if err := mergeValue(tag, mval, val.MapIndex(key), allowAll, keyPath); err != nil {
+				return err
+			}
+
+			items.SetMapIndex(mkey, mval)
+		}
+
+		target.Set(items)
+		return nil
+	}
+
+	if text, ok := val.Interface().(string); ok && reflect.PtrTo(target.Type()).Implements(textUnmarshalerType) {
+		if err := target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return &PathError{Path: path, Err: err}
+		}
+
+		return nil
+	}
+
+	canSet, mustConvert := CanSetFor(target.Type(), val)
+	if !canSet || (mustConvert && !canConvertKinds(target.Kind(), val)) {
+		return &PathError{Path: path, Err: fmt.Errorf("can not set %s into %s", val.Type(), target.Type())}
+	}
+
+	if isNumberKind(target.Kind()) && isNumberKind(val.Kind()) && !numberFits(target.Type(), val) {
+		return &PathError{Path: path, Err: fmt.Errorf("%v overflows %s", val.Interface(), target.Type())}
+	}
+
+	converted, err := Convert(target.Type(), val)
+	if err != nil {
+		return &PathError{Path: path, Err: err}
+	}
+
+	target.Set(converted)
+	return nil
+}
+
+// canConvertKinds returns true/false if the value can be converted to the
+// target kind without changing its meaning, which excludes conversions of
+// numbers into strings and of floats with a fraction into integers.
+func canConvertKinds(target reflect.Kind, val reflect.Value) bool {
+	switch {
+	case target == val.Kind():
+		return true
+	case isNumberKind(target) && isNumberKind(val.Kind()):
+		if isFloatKind(val.Kind()) && !isFloatKind(target) {
+			return val.Float() == math.Trunc(val.Float())
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// numberFits returns true/false if the numeric value can be converted to the
+// numeric target type without overflowing it or losing its sign.
+func numberFits(target reflect.Type, val reflect.Value) bool {
+	probe := reflect.Zero(target)
+
+	switch {
+	case isFloatKind(target.Kind()):
+		return !isFloatKind(val.Kind()) || !probe.OverflowFloat(val.Float())
+
+	case isUintKind(target.Kind()):
+		switch {
+		case isFloatKind(val.Kind()):
+			return val.Float() >= 0 && val.Float() < math.Exp2(64) && !probe.OverflowUint(uint64(val.Float()))
+		case isUintKind(val.Kind()):
+			return !probe.OverflowUint(val.Uint())
+		default:
+			return val.Int() >= 0 && !probe.OverflowUint(uint64(val.Int()))
+		}
+
+	default:
+		switch {
+		case isFloatKind(val.Kind()):
+			return val.Float() >= math.MinInt64 && val.Float() < math.Exp2(63) && !probe.OverflowInt(int64(val.Float()))
+		case isUintKind(val.Kind()):
+			return val.Uint() <= math.MaxInt64 && !probe.OverflowInt(int64(val.Uint()))
+		default:
+			return !probe.OverflowInt(val.Int())
+		}
+	}
+}
+
+// setString parses the giving text into the scalar target for fields with the
+// string tag option.
+func setString(target reflect.Value, text string) error {
+	if target.Kind() == reflect.Ptr {
+		item := reflect.New(target.Type().Elem())
+		if err := setString(item.Elem(), text); err != nil {
+			return err
+		}
+
+		target.Set(item)
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(text)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+
+		target.SetBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := strconv.ParseInt(text, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		target.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(text, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		target.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(text, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		target.SetFloat(val)
+	default:
+		return fmt.Errorf("string option not supported for %s", target.Type())
+	}
+
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
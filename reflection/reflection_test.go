@@ -1,11 +1,15 @@
 package reflection_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/influx6/faux/reflection"
+	_ "github.com/influx6/faux/reflection/yamlconfig"
 )
 
 // succeedMark is the Unicode codepoint for a check mark.
@@ -96,3 +100,530 @@ func TestMatchFUncArgumentTypeWithValues(t *testing.T) {
 	t.Logf("\t%s\tShould have matching new values lists for arguments", succeedMark)
 
 }
+
+type address struct {
+	Street string `json:"street"`
+	Zip    int    `json:"zip,omitempty"`
+}
+
+type audit struct {
+	Created time.Time `json:"created"`
+}
+
+type profile struct {
+	audit
+	Name     string            `json:"name"`
+	Age      int               `json:"age,string"`
+	Nick     string            `json:"nick,omitempty"`
+	Home     address           `json:"home"`
+	Work     *address          `json:"work"`
+	Past     []address         `json:"past"`
+	Labels   map[string]int    `json:"labels"`
+	Extra    address           `json:"extra,inline"`
+	Scores   []float64         `json:"scores"`
+	Settings map[string]string `json:"-"`
+	secret   string
+}
+
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next"`
+}
+
+// TestToMap validates the conversion of nested structs into maps.
+func TestToMap(t *testing.T) {
+	created := time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	item := profile{
+		audit:  audit{Created: created},
+		Name:   "alex",
+		Age:    20,
+		Home:   address{Street: "home road", Zip: 300},
+		Work:   &address{Street: "work road"},
+		Past:   []address{{Street: "old road"}},
+		Labels: map[string]int{"a": 1},
+		Extra:  address{Street: "extra road"},
+		secret: "hidden",
+	}
+
+	data, err := reflection.ToMap("json", &item, true)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to convert struct to map: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould be able to convert struct to map", succeedMark)
+
+	expected := map[string]interface{}{
+		"created": created,
+		"name":    "alex",
+		"age":     "20",
+		"home":    map[string]interface{}{"street": "home road", "zip": 300},
+		"work":    map[string]interface{}{"street": "work road"},
+		"past":    []interface{}{map[string]interface{}{"street": "old road"}},
+		"labels":  map[string]interface{}{"a": 1},
+		"street":  "extra road",
+		"scores":  nil,
+	}
+
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("\t%s\tShould match expected map: %#v", failedMark, data)
+	}
+	t.Logf("\t%s\tShould match expected map", succeedMark)
+
+	first := &node{Name: "first"}
+	first.Next = &node{Name: "second", Next: first}
+
+	_, err = reflection.ToMap("json", first, true)
+	if perr, ok := err.(*reflection.PathError); !ok || perr.Path != "next.next" {
+		t.Fatalf("\t%s\tShould have failed with path of cycle: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have failed with path of cycle", succeedMark)
+}
+
+// TestMergeMap validates the merging of nested maps into structs.
+func TestMergeMap(t *testing.T) {
+	var item profile
+
+	err := reflection.MergeMap("json", &item, map[string]interface{}{
+		"created": "2017-03-04T00:00:00Z",
+		"name":    "alex",
+		"age":     "20",
+		"home":    map[string]interface{}{"street": "home road", "zip": float64(300)},
+		"work":    map[string]interface{}{"street": "work road"},
+		"past":    []interface{}{map[string]interface{}{"street": "old road"}},
+		"labels":  map[string]interface{}{"a": float64(1)},
+		"street":  "extra road",
+		"scores":  []interface{}{1, 2.5},
+	}, true)
+
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to merge map into struct: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould be able to merge map into struct", succeedMark)
+
+	expected := profile{
+		audit:  audit{Created: time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)},
+		Name:   "alex",
+		Age:    20,
+		Home:   address{Street: "home road", Zip: 300},
+		Work:   &address{Street: "work road"},
+		Past:   []address{{Street: "old road"}},
+		Labels: map[string]int{"a": 1},
+		Extra:  address{Street: "extra road"},
+		Scores: []float64{1, 2.5},
+	}
+
+	if !reflect.DeepEqual(item, expected) {
+		t.Fatalf("\t%s\tShould match expected struct: %#v", failedMark, item)
+	}
+	t.Logf("\t%s\tShould match expected struct", succeedMark)
+
+	err = reflection.MergeMap("json", &item, map[string]interface{}{
+		"past": []interface{}{map[string]interface{}{"zip": 1.5}},
+	}, true)
+
+	if perr, ok := err.(*reflection.PathError); !ok || perr.Path != "past[0].zip" {
+		t.Fatalf("\t%s\tShould have failed with path of bad value: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have failed with path of bad value: %s", succeedMark, err)
+
+	err = reflection.MergeMap("json", &item, map[string]interface{}{
+		"name": 20,
+	}, true)
+
+	if perr, ok := err.(*reflection.PathError); !ok || perr.Path != "name" {
+		t.Fatalf("\t%s\tShould have refused number for string field: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have refused number for string field: %s", succeedMark, err)
+
+	type sized struct {
+		Small uint8   `json:"small"`
+		Count int     `json:"count"`
+		Total uint    `json:"total"`
+		Ratio float32 `json:"ratio"`
+	}
+
+	for _, value := range []map[string]interface{}{
+		{"small": 300},
+		{"small": float64(-1)},
+		{"count": 1e30},
+		{"count": uint64(math.MaxUint64)},
+		{"total": -1},
+		{"ratio": 1e300},
+	} {
+		var target sized
+		if err := reflection.MergeMap("json", &target, value, true); err == nil {
+			t.Fatalf("\t%s\tShould have refused overflowing number %+v: %+v", failedMark, value, target)
+		} else if _, ok := err.(*reflection.PathError); !ok {
+			t.Fatalf("\t%s\tShould have returned path error for overflow: %#v", failedMark, err)
+		}
+	}
+	t.Logf("\t%s\tShould have refused overflowing numbers", succeedMark)
+
+	var target sized
+	if err := reflection.MergeMap("json", &target, map[string]interface{}{"small": float64(255), "count": -5, "total": 7}, true); err != nil || target.Small != 255 || target.Count != -5 || target.Total != 7 {
+		t.Fatalf("\t%s\tShould have set numbers within range: %+v %v", failedMark, target, err)
+	}
+	t.Logf("\t%s\tShould have set numbers within range", succeedMark)
+}
+
+type signup struct {
+	Name     string   `json:"name" validate:"required,min=3,max=20"`
+	Email    string   `json:"email" validate:"required,email"`
+	Backup   string   `json:"backup" validate:"omitempty,email"`
+	Role     string   `json:"role" validate:"oneof=admin user"`
+	Code     string   `json:"code" validate:"regex=^[a-z]{2,3}$"`
+	Password string   `json:"password" validate:"min=8"`
+	Confirm  string   `json:"confirm" validate:"eqfield=Password"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Team     string   `json:"team" validate:"even"`
+	Homes    []address
+	Work     *signupAddress `json:"work"`
+}
+
+type signupAddress struct {
+	Street string `json:"street" validate:"required"`
+}
+
+// TestValidate validates the validate tag rules of nested structs.
+func TestValidate(t *testing.T) {
+	if err := reflection.RegisterValidator("even", func(value reflect.Value, _ string, _ reflect.Value) error {
+		if value.Len()%2 != 0 {
+			return fmt.Errorf("length must be even")
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("\t%s\tShould be able to register validator: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould be able to register validator", succeedMark)
+
+	valid := signup{
+		Name:     "alex",
+		Email:    "alex@example.com",
+		Role:     "admin",
+		Code:     "ab",
+		Password: "password",
+		Confirm:  "password",
+		Team:     "ab",
+		Work:     &signupAddress{Street: "work road"},
+	}
+
+	if err := reflection.Validate(&valid); err != nil {
+		t.Fatalf("\t%s\tShould have validated struct: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have validated struct", succeedMark)
+
+	err := reflection.Validate(signup{
+		Name:     "al",
+		Backup:   "not-an-email",
+		Role:     "root",
+		Code:     "a,b",
+		Password: "password",
+		Confirm:  "passw0rd",
+		Tags:     []string{"a", "b", "c"},
+		Team:     "abc",
+		Work:     &signupAddress{},
+	})
+
+	errs, ok := err.(reflection.ValidationErrors)
+	if !ok {
+		t.Fatalf("\t%s\tShould have received ValidationErrors: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have received ValidationErrors", succeedMark)
+
+	var got []string
+	for _, ferr := range errs {
+		got = append(got, ferr.Path+":"+ferr.Rule)
+	}
+
+	expected := []string{
+		"name:min", "email:required", "backup:email", "role:oneof", "code:regex",
+		"confirm:eqfield", "tags:max", "team:even", "work.street:required",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("\t%s\tShould have failed expected rules: %+q\n%s", failedMark, got, err)
+	}
+	t.Logf("\t%s\tShould have failed expected rules:\n%s", succeedMark, err)
+
+	err = reflection.Validate(struct {
+		Name string `validate:"unknown"`
+	}{})
+
+	if perr, ok := err.(*reflection.PathError); !ok || perr.Path != "Name" {
+		t.Fatalf("\t%s\tShould have failed for unknown validator: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have failed for unknown validator", succeedMark)
+}
+
+// TestValidateSlices validates the rules of structs within slices.
+func TestValidateSlices(t *testing.T) {
+	type member struct {
+		Email string `json:"email" validate:"email"`
+	}
+
+	type group struct {
+		Members []member          `json:"members" validate:"min=1"`
+		Leads   map[string]member `json:"leads"`
+	}
+
+	err := reflection.Validate(group{
+		Members: []member{{Email: "a@example.com"}, {Email: "bad"}},
+		Leads:   map[string]member{"ops": {Email: "bad"}},
+	})
+
+	errs, ok := err.(reflection.ValidationErrors)
+	if !ok || len(errs) != 2 || errs[0].Path != "members[1].email" || errs[1].Path != "leads.ops.email" {
+		t.Fatalf("\t%s\tShould have failed with paths of nested structs: %#v", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have failed with paths of nested structs", succeedMark)
+}
+
+type cloneable struct {
+	Value  string
+	cloned bool
+}
+
+// Clone returns a copy of the cloneable marked as cloned.
+func (c cloneable) Clone() cloneable {
+	return cloneable{Value: c.Value, cloned: true}
+}
+
+type settings struct {
+	Name    string            `json:"name"`
+	Limits  map[string]int    `json:"limits"`
+	Hosts   []string          `json:"hosts"`
+	Parent  *settings         `json:"parent"`
+	Custom  cloneable         `json:"custom"`
+	Started time.Time         `json:"started"`
+	Extra   map[string]string `json:"extra"`
+	secret  []int
+}
+
+// TestDeepCopy validates the deep copying of values.
+func TestDeepCopy(t *testing.T) {
+	item := &settings{
+		Name:    "main",
+		Limits:  map[string]int{"cpu": 2},
+		Hosts:   []string{"a", "b"},
+		Custom:  cloneable{Value: "custom"},
+		Started: time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC),
+		secret:  []int{1, 2},
+	}
+	item.Parent = item
+
+	copied := reflection.DeepCopy(item).(*settings)
+
+	if copied == item || copied.Parent != copied {
+		t.Fatalf("\t%s\tShould have copied pointer preserving cycle", failedMark)
+	}
+	t.Logf("\t%s\tShould have copied pointer preserving cycle", succeedMark)
+
+	copied.Limits["cpu"] = 4
+	copied.Hosts[0] = "c"
+
+	if item.Limits["cpu"] != 2 || item.Hosts[0] != "a" {
+		t.Fatalf("\t%s\tShould have copied maps and slices", failedMark)
+	}
+	t.Logf("\t%s\tShould have copied maps and slices", succeedMark)
+
+	if len(copied.secret) != 2 || &copied.secret[0] != &item.secret[0] {
+		t.Fatalf("\t%s\tShould have copied unexported fields by value: %+v", failedMark, copied.secret)
+	}
+	t.Logf("\t%s\tShould have copied unexported fields by value", succeedMark)
+
+	if !copied.Started.Equal(item.Started) || copied.Started.IsZero() {
+		t.Fatalf("\t%s\tShould have kept time fields: %s", failedMark, copied.Started)
+	}
+	t.Logf("\t%s\tShould have kept time fields", succeedMark)
+
+	if changes := reflection.Diff(*item, reflection.DeepCopy(*item)); len(changes) != 0 {
+		t.Fatalf("\t%s\tShould have found no changes in copy:\n%s", failedMark, changes)
+	}
+	t.Logf("\t%s\tShould have found no changes in copy", succeedMark)
+
+	if !copied.Custom.cloned || copied.Custom.Value != "custom" {
+		t.Fatalf("\t%s\tShould have copied with Clone method", failedMark)
+	}
+	t.Logf("\t%s\tShould have copied with Clone method", succeedMark)
+
+	full := reflection.DeepCopyWith(*item, reflection.CopyOptions{Unexported: true}).(settings)
+	full.secret[0] = 10
+
+	if item.secret[0] != 1 || len(full.secret) != 2 {
+		t.Fatalf("\t%s\tShould have copied unexported fields: %+v", failedMark, full.secret)
+	}
+	t.Logf("\t%s\tShould have copied unexported fields", succeedMark)
+}
+
+// TestDiff validates the changes found between values.
+func TestDiff(t *testing.T) {
+	before := settings{
+		Name:    "main",
+		Limits:  map[string]int{"cpu": 2, "disk": 10},
+		Hosts:   []string{"a", "b"},
+		Started: time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC),
+	}
+
+	after := reflection.DeepCopy(before).(settings)
+	after.Name = "backup"
+	after.Limits["cpu"] = 4
+	after.Limits["mem"] = 8
+	delete(after.Limits, "disk")
+	after.Hosts = append(after.Hosts, "c")
+	after.Started = before.Started.In(time.FixedZone("X", 3600))
+
+	changes := reflection.Diff(before, after)
+
+	expected := reflection.Changes{
+		{Kind: reflection.Modified, Path: "name", Old: "main", New: "backup"},
+		{Kind: reflection.Modified, Path: "limits.cpu", Old: 2, New: 4},
+		{Kind: reflection.Removed, Path: "limits.disk", Old: 10},
+		{Kind: reflection.Added, Path: "limits.mem", New: 8},
+		{Kind: reflection.Added, Path: "hosts[2]", New: "c"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("\t%s\tShould have found expected changes:\n%s", failedMark, changes)
+	}
+	t.Logf("\t%s\tShould have found expected changes:\n%s", succeedMark, changes)
+
+	if len(reflection.Diff(before, before)) != 0 {
+		t.Fatalf("\t%s\tShould have found no changes for equal values", failedMark)
+	}
+	t.Logf("\t%s\tShould have found no changes for equal values", succeedMark)
+}
+
+type serverLimits struct {
+	Conns int `json:"conns" default:"100" validate:"min=1"`
+}
+
+type serverConfig struct {
+	Addr    string            `json:"addr" desc:"address to listen on" validate:"required"`
+	Mode    string            `json:"mode" default:"http" validate:"oneof=http https"`
+	Admin   string            `json:"admin,omitempty" validate:"omitempty,email"`
+	Hosts   []string          `json:"hosts" validate:"max=2"`
+	Labels  map[string]string `json:"labels"`
+	Limits  serverLimits      `json:"limits"`
+	Timeout time.Duration     `json:"timeout" default:"5"`
+}
+
+// TestSchemaOf validates the schema generated for a struct type.
+func TestSchemaOf(t *testing.T) {
+	schema, err := reflection.SchemaOf(reflect.TypeOf(serverConfig{}))
+	if err != nil {
+		t.Fatalf("\t%s\tShould have generated schema: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have generated schema:\n%s", succeedMark, schema)
+
+	if !reflect.DeepEqual(schema.Required, []string{"addr"}) {
+		t.Fatalf("\t%s\tShould have set required properties: %+q", failedMark, schema.Required)
+	}
+	t.Logf("\t%s\tShould have set required properties", succeedMark)
+
+	addr := schema.Properties["addr"]
+	if addr == nil || addr.Type != "string" || addr.Description != "address to listen on" {
+		t.Fatalf("\t%s\tShould have described addr property: %+v", failedMark, addr)
+	}
+	t.Logf("\t%s\tShould have described addr property", succeedMark)
+
+	mode := schema.Properties["mode"]
+	if mode.Default != "http" || !reflect.DeepEqual(mode.Enum, []interface{}{"http", "https"}) {
+		t.Fatalf("\t%s\tShould have set default and enum of mode: %+v", failedMark, mode)
+	}
+	t.Logf("\t%s\tShould have set default and enum of mode", succeedMark)
+
+	if admin := schema.Properties["admin"]; admin.Format != "" {
+		t.Fatalf("\t%s\tShould have left omitempty rules to Validate: %+v", failedMark, admin)
+	}
+	t.Logf("\t%s\tShould have left omitempty rules to Validate", succeedMark)
+
+	limits := schema.Properties["limits"]
+	if !reflect.DeepEqual(limits.Default, map[string]interface{}{"conns": float64(100)}) {
+		t.Fatalf("\t%s\tShould have set nested defaults on struct property: %+v", failedMark, limits.Default)
+	}
+	t.Logf("\t%s\tShould have set nested defaults on struct property", succeedMark)
+
+	if _, err := reflection.SchemaOf(reflect.TypeOf(struct{ C chan int }{})); err == nil {
+		t.Fatalf("\t%s\tShould have failed for unsupported field types", failedMark)
+	}
+	t.Logf("\t%s\tShould have failed for unsupported field types", succeedMark)
+}
+
+// TestDecodeConfig validates decoding of JSON and YAML configs.
+func TestDecodeConfig(t *testing.T) {
+	var config serverConfig
+
+	yml := []byte("addr: :8080\nhosts:\n  - a\nlabels:\n  env: prod\n")
+	if err := reflection.DecodeConfig(yml, &config); err != nil {
+		t.Fatalf("\t%s\tShould have decoded YAML config: %s", failedMark, err)
+	}
+	t.Logf("\t%s\tShould have decoded YAML config", succeedMark)
+
+	expected := serverConfig{
+		Addr:    ":8080",
+		Mode:    "http",
+		Hosts:   []string{"a"},
+		Labels:  map[string]string{"env": "prod"},
+		Limits:  serverLimits{Conns: 100},
+		Timeout: 5,
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("\t%s\tShould have applied defaults: %+v", failedMark, config)
+	}
+	t.Logf("\t%s\tShould have applied defaults", succeedMark)
+
+	raw := []byte(`{"mode": "ftp", "hosts": ["a", "b", "c"], "limits": {"conns": 0}, "port": 80, "admin": ""}`)
+
+	err := reflection.DecodeConfig(raw, &serverConfig{})
+	errs, ok := err.(reflection.ValidationErrors)
+	if !ok {
+		t.Fatalf("\t%s\tShould have returned ValidationErrors: %#v", failedMark, err)
+	}
+
+	var paths []string
+	for _, item := range errs {
+		paths = append(paths, item.Path+":"+item.Rule)
+	}
+
+	want := []string{"addr:required", "hosts:maxItems", "limits.conns:minimum", "mode:enum", "port:additionalProperties"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("\t%s\tShould have reported schema errors: %+q", failedMark, paths)
+	}
+	t.Logf("\t%s\tShould have reported schema errors:\n%s", succeedMark, errs)
+
+	if err := reflection.DecodeConfig([]byte(`{"addr": 20}`), &serverConfig{}); err == nil {
+		t.Fatalf("\t%s\tShould have failed for invalid property type", failedMark)
+	}
+	t.Logf("\t%s\tShould have failed for invalid property type", succeedMark)
+}
+
+// TestDecodeConfigIntegers validates integers above 2^53 are decoded exactly.
+func TestDecodeConfigIntegers(t *testing.T) {
+	type idConfig struct {
+		ID    int64  `json:"id"`
+		Count uint64 `json:"count"`
+	}
+
+	for _, raw := range []string{
+		`{"id": 9007199254740993, "count": 18446744073709551615}`,
+		"id: 9007199254740993\ncount: 18446744073709551615\n",
+	} {
+		var config idConfig
+		if err := reflection.DecodeConfig([]byte(raw), &config); err != nil {
+			t.Fatalf("\t%s\tShould have decoded config %q: %s", failedMark, raw, err)
+		}
+
+		if config.ID != 9007199254740993 || config.Count != math.MaxUint64 {
+			t.Fatalf("\t%s\tShould have kept integers exact: %+v", failedMark, config)
+		}
+	}
+	t.Logf("\t%s\tShould have kept integers exact", succeedMark)
+
+	value, err := reflection.DecodeRaw([]byte("id: 42\n"))
+	if err != nil || !reflect.DeepEqual(value, map[string]interface{}{"id": json.Number("42")}) {
+		t.Fatalf("\t%s\tShould have decoded YAML integers as json.Number: %#v %v", failedMark, value, err)
+	}
+	t.Logf("\t%s\tShould have decoded YAML integers as json.Number", succeedMark)
+}
@@ -0,0 +1,561 @@
+package reflection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// contains the struct tags read by SchemaOf.
+const (
+	// DefaultTag defines the struct tag holding the default value of a field,
+	// e.g `default:"8080"`. Arrays and objects use their JSON form.
+	DefaultTag = "default"
+
+	// DescTag defines the struct tag holding the description of a field.
+	DescTag = "desc"
+)
+
+// SchemaDraft defines the JSON Schema draft declared by SchemaOf.
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema defines the subset of JSON Schema generated by SchemaOf for Go types.
+type Schema struct {
+	Schema      string      `json:"$schema,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+
+	// Enum sets the values allowed, taken from the oneof rule.
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// Format sets the format of strings, e.g email or date-time.
+	Format          string `json:"format,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	MinLength       *int   `json:"minLength,omitempty"`
+	MaxLength       *int   `json:"maxLength,omitempty"`
+
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+
+	// AdditionalProperties sets the schema of object keys not found in
+	// Properties, either a *Schema for maps or false for structs.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// String returns the indented JSON form of the schema.
+func (s *Schema) String() string {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(data)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the JSON Schema of the values decoded by encoding/json into
+// the giving type. Properties are named after the json tag of fields, with
+// their description and default taken from the desc and default tags. The
+// validate tag sets the required properties and, for fields without the
+// omitempty rule, the min, max, oneof, email and regex constraints. Other rules
+// are only checked by Validate, see DecodeConfig.
+//
+// Structs reject unknown properties. Recursive structs are described by an
+// empty schema which accepts any value at the point of recursion.
+func SchemaOf(tl reflect.Type) (*Schema, error) {
+	schema, err := schemaOf(tl, "", make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Schema = SchemaDraft
+	schema.Title = tl.String()
+
+	return schema, nil
+}
+
+// schemaOf returns the schema of the type at the giving path.
+func schemaOf(tl reflect.Type, path string, stack map[reflect.Type]bool) (*Schema, error) {
+	if tl == timeType {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	if tl.Implements(textMarshalerType) || (tl.Kind() != reflect.Ptr && reflect.PtrTo(tl).Implements(textMarshalerType)) {
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch tl.Kind() {
+	case reflect.Ptr:
+		return schemaOf(tl.Elem(), path, stack)
+
+	case reflect.Interface:
+		return &Schema{}, nil
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+
+	case reflect.Slice, reflect.Array:
+		if tl.Kind() == reflect.Slice && tl.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+
+		items, err := schemaOf(tl.Elem(), path+"[]", stack)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		switch key := tl.Key(); {
+		case key.Kind() == reflect.String, isNumberKind(key.Kind()) && !isFloatKind(key.Kind()), key.Implements(textMarshalerType):
+		default:
+			return nil, &PathError{Path: path, Err: fmt.Errorf("unsupported map key type %s", key)}
+		}
+
+		values, err := schemaOf(tl.Elem(), path+"[]", stack)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Struct:
+		if stack[tl] {
+			return &Schema{}, nil
+		}
+
+		stack[tl] = true
+		defer delete(stack, tl)
+
+		schema := &Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*Schema),
+			AdditionalProperties: false,
+		}
+
+		if err := structSchema(tl, path, schema, stack); err != nil {
+			return nil, err
+		}
+
+		return schema, nil
+
+	default:
+		return nil, &PathError{Path: path, Err: fmt.Errorf("unsupported type %s", tl)}
+	}
+}
+
+// structSchema adds the properties of the struct type into the schema.
+func structSchema(tl reflect.Type, path string, schema *Schema, stack map[reflect.Type]bool) error {
+	for i := 0; i < tl.NumField(); i++ {
+		sf := tl.Field(i)
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _ := parseTag(tag)
+
+		// Embedded structs without a json name have their fields promoted.
+		if sf.Anonymous && name == "" && isStructType(sf.Type) {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if err := structSchema(ft, path, schema, stack); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name = jsonName(sf)
+		fieldPath := joinPath(path, name)
+
+		prop, err := schemaOf(sf.Type, fieldPath, stack)
+		if err != nil {
+			return err
+		}
+
+		prop.Description = sf.Tag.Get(DescTag)
+
+		if value, ok := sf.Tag.Lookup(DefaultTag); ok {
+			def, err := parseDefault(prop, value)
+			if err != nil {
+				return &PathError{Path: fieldPath, Err: err}
+			}
+
+			prop.Default = def
+		} else if sf.Type.Kind() == reflect.Struct {
+			// Defaults of nested structs are set on their property, so they
+			// apply when the property is missing.
+			if defs := defaultsOf(prop); len(defs) != 0 {
+				prop.Default = defs
+			}
+		}
+
+		required, err := schemaRules(prop, sf.Tag.Get(ValidateTag))
+		if err != nil {
+			return &PathError{Path: fieldPath, Err: err}
+		}
+
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = prop
+	}
+
+	return nil
+}
+
+// schemaRules sets the constraints of the validate tag on the schema and
+// returns true/false if the field is required.
+func schemaRules(schema *Schema, tag string) (bool, error) {
+	rules := parseRules(tag)
+
+	var required, omitempty bool
+	for _, item := range rules {
+		switch item.name {
+		case "required":
+			required = true
+		case "omitempty":
+			omitempty = true
+		}
+	}
+
+	// Empty values skip the rules of omitempty fields, which a schema can
+	// not express, so they are left to Validate.
+	if omitempty {
+		return required, nil
+	}
+
+	for _, item := range rules {
+		switch item.name {
+		case "min", "max":
+			bound, err := strconv.ParseFloat(item.param, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s %q", item.name, item.param)
+			}
+
+			setBound(schema, item.name == "min", bound)
+
+		case "oneof":
+			for _, value := range strings.Fields(item.param) {
+				def, err := parseDefault(schema, value)
+				if err != nil {
+					return false, fmt.Errorf("invalid oneof value %q: %s", value, err)
+				}
+
+				schema.Enum = append(schema.Enum, def)
+			}
+
+		case "email":
+			schema.Format = "email"
+
+		case "regex":
+			schema.Pattern = item.param
+		}
+	}
+
+	return required, nil
+}
+
+// setBound sets the min or max bound of the schema based on its type.
+func setBound(schema *Schema, min bool, bound float64) {
+	switch schema.Type {
+	case "string":
+		size := int(bound)
+		if min {
+			schema.MinLength = &size
+		} else {
+			schema.MaxLength = &size
+		}
+
+	case "array":
+		size := int(bound)
+		if min {
+			schema.MinItems = &size
+		} else {
+			schema.MaxItems = &size
+		}
+
+	case "integer", "number":
+		if min {
+			schema.Minimum = &bound
+		} else {
+			schema.Maximum = &bound
+		}
+	}
+}
+
+// parseDefault returns the value of a default tag for the schema type.
+func parseDefault(schema *Schema, value string) (interface{}, error) {
+	switch schema.Type {
+	case "string":
+		return value, nil
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer", "number":
+		num, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if schema.Type == "integer" && num != math.Trunc(num) {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+
+		return num, nil
+	default:
+		var def interface{}
+		if err := json.Unmarshal([]byte(value), &def); err != nil {
+			return nil, err
+		}
+
+		return def, nil
+	}
+}
+
+// defaultsOf returns the defaults of the properties of an object schema.
+func defaultsOf(schema *Schema) map[string]interface{} {
+	defs := make(map[string]interface{})
+
+	for name, prop := range schema.Properties {
+		if prop.Default != nil {
+			defs[name] = prop.Default
+		}
+	}
+
+	return defs
+}
+
+//==============================================================================
+
+// ApplyDefaults returns the value with the defaults of the schema set for
+// missing object properties, where value is a decoded JSON value such as a
+// map[string]interface{}. Maps in the value are updated in place.
+func (s *Schema) ApplyDefaults(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+
+	if value == nil && s.Default != nil {
+		value = DeepCopy(s.Default)
+	}
+
+	switch item := value.(type) {
+	case map[string]interface{}:
+		for name, prop := range s.Properties {
+			if _, ok := item[name]; !ok && prop.Default == nil {
+				continue
+			}
+
+			item[name] = prop.ApplyDefaults(item[name])
+		}
+
+	case []interface{}:
+		for index := range item {
+			item[index] = s.Items.ApplyDefaults(item[index])
+		}
+	}
+
+	return value
+}
+
+// Validate validates the decoded JSON value against the schema, returning
+// ValidationErrors with the path and keyword of each failure. Null values are
+// accepted for all types, as they decode into the zero value.
+func (s *Schema) Validate(value interface{}) error {
+	var errs ValidationErrors
+	s.validate(value, "", &errs)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// validate validates the value at the path.
+func (s *Schema) validate(value interface{}, path string, errs *ValidationErrors) {
+	if s == nil || value == nil {
+		return
+	}
+
+	fail := func(rule string, param interface{}, format string, args ...interface{}) {
+		var pm string
+		if param != nil {
+			pm = fmt.Sprintf("%v", param)
+		}
+
+		*errs = append(*errs, FieldError{Path: path, Rule: rule, Param: pm, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !isSchemaType(s.Type, value) {
+		fail("type", s.Type, "must be of type %s", s.Type)
+		return
+	}
+
+	if len(s.Enum) != 0 {
+		var found bool
+		for _, item := range s.Enum {
+			if reflect.DeepEqual(item, value) || reflect.DeepEqual(item, numberOf(value)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			fail("enum", nil, "must be one of %v", s.Enum)
+		}
+	}
+
+	switch item := value.(type) {
+	case string:
+		size := utf8.RuneCountInString(item)
+
+		if s.MinLength != nil && size < *s.MinLength {
+			fail("minLength", *s.MinLength, "length must be at least %d", *s.MinLength)
+		}
+
+		if s.MaxLength != nil && size > *s.MaxLength {
+			fail("maxLength", *s.MaxLength, "length must be at most %d", *s.MaxLength)
+		}
+
+		if s.Pattern != "" {
+			if err := validateRegex(reflect.ValueOf(item), s.Pattern, reflect.Value{}); err != nil {
+				fail("pattern", s.Pattern, "%s", err)
+			}
+		}
+
+		switch s.Format {
+		case "email":
+			if err := validateEmail(reflect.ValueOf(item), "", reflect.Value{}); err != nil {
+				fail("format", s.Format, "%s", err)
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, item); err != nil {
+				fail("format", s.Format, "must be a RFC3339 date-time")
+			}
+		}
+
+	case float64, json.Number:
+		num := numberOf(item).(float64)
+
+		if s.Minimum != nil && num < *s.Minimum {
+			fail("minimum", *s.Minimum, "value must be at least %v", *s.Minimum)
+		}
+
+		if s.Maximum != nil && num > *s.Maximum {
+			fail("maximum", *s.Maximum, "value must be at most %v", *s.Maximum)
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(item) < *s.MinItems {
+			fail("minItems", *s.MinItems, "length must be at least %d", *s.MinItems)
+		}
+
+		if s.MaxItems != nil && len(item) > *s.MaxItems {
+			fail("maxItems", *s.MaxItems, "length must be at most %d", *s.MaxItems)
+		}
+
+		for index, elem := range item {
+			s.Items.validate(elem, fmt.Sprintf("%s[%d]", path, index), errs)
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := item[name]; !ok {
+				*errs = append(*errs, FieldError{Path: joinPath(path, name), Rule: "required", Message: "is required"})
+			}
+		}
+
+		for _, name := range sortedKeys(item) {
+			elemPath := joinPath(path, name)
+
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(item[name], elemPath, errs)
+				continue
+			}
+
+			switch extra := s.AdditionalProperties.(type) {
+			case bool:
+				if !extra {
+					*errs = append(*errs, FieldError{Path: elemPath, Rule: "additionalProperties", Message: "is not allowed"})
+				}
+			case *Schema:
+				extra.validate(item[name], elemPath, errs)
+			}
+		}
+	}
+}
+
+// isSchemaType returns true/false if the decoded JSON value is of the type.
+func isSchemaType(kind string, value interface{}) bool {
+	switch item := value.(type) {
+	case string:
+		return kind == "string"
+	case bool:
+		return kind == "boolean"
+	case float64:
+		return kind == "number" || (kind == "integer" && item == math.Trunc(item))
+	case json.Number:
+		if _, err := item.Int64(); err == nil {
+			return kind == "number" || kind == "integer"
+		}
+
+		num, err := item.Float64()
+		return err == nil && (kind == "number" || (kind == "integer" && num == math.Trunc(num)))
+	case []interface{}:
+		return kind == "array"
+	case map[string]interface{}:
+		return kind == "object"
+	default:
+		return false
+	}
+}
+
+// numberOf returns the json.Number as a float64 for comparisons, leaving other
+// values unchanged.
+func numberOf(value interface{}) interface{} {
+	if num, ok := value.(json.Number); ok {
+		if val, err := num.Float64(); err == nil {
+			return val
+		}
+	}
+
+	return value
+}
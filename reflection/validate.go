@@ -0,0 +1,458 @@
+package reflection
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidateTag defines the struct tag read by Validate for the rules of a field,
+// e.g `validate:"required,min=3,max=20"`.
+const ValidateTag = "validate"
+
+// ValidatorFunc defines a function which validates the value of a field using
+// the param of its rule, e.g the 3 in min=3. The parent is the struct holding
+// the field, which allows rules comparing fields. It returns an error with a
+// message describing why the value is invalid.
+type ValidatorFunc func(value reflect.Value, param string, parent reflect.Value) error
+
+// FieldError defines the failure of a rule for a single field.
+type FieldError struct {
+	// Path sets the JSON path of the field, using the names of the json tag
+	// where available, e.g users[0].email.
+	Path string `json:"path"`
+
+	// Rule sets the name of the failed rule, e.g min.
+	Rule string `json:"rule"`
+
+	// Param sets the param of the failed rule, e.g 3 for min=3.
+	Param string `json:"param,omitempty"`
+
+	// Message sets the reason the value failed the rule.
+	Message string `json:"message"`
+}
+
+// Error returns the path and message of the field error.
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", f.Path, f.Message)
+}
+
+// ValidationErrors defines the list of field errors returned by Validate.
+type ValidationErrors []FieldError
+
+// Error returns the messages of all field errors joined by a newline.
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, err := range v {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+var validators = struct {
+	vl    sync.RWMutex
+	items map[string]ValidatorFunc
+}{
+	items: map[string]ValidatorFunc{
+		"min":     validateMin,
+		"max":     validateMax,
+		"email":   validateEmail,
+		"oneof":   validateOneOf,
+		"regex":   validateRegex,
+		"eqfield": validateEqField,
+	},
+}
+
+// RegisterValidator adds the giving validator under the provided name,
+// replacing any existing validator with the same name, including the builtin
+// ones. The names required and omitempty are reserved.
+func RegisterValidator(name string, fn ValidatorFunc) error {
+	if name == "required" || name == "omitempty" {
+		return fmt.Errorf("reflection: validator name %q is reserved", name)
+	}
+
+	validators.vl.Lock()
+	defer validators.vl.Unlock()
+
+	validators.items[name] = fn
+	return nil
+}
+
+// GetValidator returns the validator registered under the giving name.
+func GetValidator(name string) (ValidatorFunc, bool) {
+	validators.vl.RLock()
+	defer validators.vl.RUnlock()
+
+	fn, ok := validators.items[name]
+	return fn, ok
+}
+
+//==============================================================================
+
+// rule defines a single parsed rule of a validate tag.
+type rule struct {
+	name  string
+	param string
+}
+
+// parseRules returns the rules of a validate tag. The regex rule consumes the
+// rest of the tag, so its expression may contain commas.
+func parseRules(tag string) []rule {
+	var rules []rule
+
+	for tag != "" {
+		var part string
+
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if index := strings.Index(tag, ","); index != -1 {
+			part, tag = tag[:index], tag[index+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, param := part, ""
+		if index := strings.Index(part, "="); index != -1 {
+			name, param = part[:index], part[index+1:]
+		}
+
+		rules = append(rules, rule{name: name, param: param})
+	}
+
+	return rules
+}
+
+// Validate validates the fields of the giving struct using the rules in their
+// validate tags, recursing into nested structs, pointers, slices and maps. It
+// returns ValidationErrors with all failed rules, or a *PathError if a rule is
+// unknown.
+//
+// The builtin rules are required, omitempty (skips other rules for empty
+// values), min and max (length for strings, slices and maps, else the value),
+// email, oneof (space separated values), regex (must be the last rule) and
+// eqfield (name of a field in the same struct which must be equal).
+func Validate(elem interface{}) error {
+	if !IsStruct(elem) {
+		return ErrNotStruct
+	}
+
+	tl := reflect.ValueOf(elem)
+
+	if tl.Kind() == reflect.Ptr {
+		if tl.IsNil() {
+			return ErrNotStruct
+		}
+
+		tl = tl.Elem()
+	}
+
+	var errs ValidationErrors
+
+	if err := validateStruct(tl, "", &errs, make(map[uintptr]bool)); err != nil {
+		return err
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// validateStruct validates the fields of the struct value.
+func validateStruct(tl reflect.Value, path string, errs *ValidationErrors, seen map[uintptr]bool) error {
+	fields, err := GetTagFields(reflect.New(tl.Type()).Interface(), ValidateTag, false)
+	if err != nil {
+		return err
+	}
+
+	tagged := make(map[int]string, len(fields))
+	for _, field := range fields {
+		tagged[field.Index] = field.Tag
+	}
+
+	tt := tl.Type()
+
+	for i := 0; i < tt.NumField(); i++ {
+		sf := tt.Field(i)
+		fl := tl.Field(i)
+
+		// Skip unexported fields except embedded structs.
+		if sf.PkgPath != "" && !(sf.Anonymous && isStructType(sf.Type)) {
+			continue
+		}
+
+		fieldPath := path
+		if !sf.Anonymous || sf.Tag.Get("json") != "" {
+			fieldPath = joinPath(path, jsonName(sf))
+		}
+
+		if tag, ok := tagged[i]; ok {
+			if err := validateRules(fl, tl, parseRules(tag), fieldPath, errs); err != nil {
+				return err
+			}
+		}
+
+		if err := validateNested(fl, fieldPath, errs, seen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRules applies the rules to the giving field value.
+func validateRules(fl reflect.Value, parent reflect.Value, rules []rule, path string, errs *ValidationErrors) error {
+	empty := isZeroValue(fl)
+
+	for _, item := range rules {
+		if item.name == "omitempty" && empty {
+			return nil
+		}
+	}
+
+	for _, item := range rules {
+		switch item.name {
+		case "omitempty":
+			continue
+		case "required":
+			if empty {
+				*errs = append(*errs, FieldError{Path: path, Rule: item.name, Message: "is required"})
+				return nil
+			}
+
+			continue
+		}
+
+		fn, ok := GetValidator(item.name)
+		if !ok {
+			return &PathError{Path: path, Err: fmt.Errorf("unknown validator %q", item.name)}
+		}
+
+		value := fl
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				break
+			}
+
+			value = value.Elem()
+		}
+
+		if err := fn(value, item.param, parent); err != nil {
+			*errs = append(*errs, FieldError{Path: path, Rule: item.name, Param: item.param, Message: err.Error()})
+		}
+	}
+
+	return nil
+}
+
+// validateNested validates structs found within the giving value.
+func validateNested(fl reflect.Value, path string, errs *ValidationErrors, seen map[uintptr]bool) error {
+	switch fl.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if fl.IsNil() {
+			return nil
+		}
+
+		if fl.Kind() == reflect.Ptr {
+			if seen[fl.Pointer()] {
+				return nil
+			}
+
+			seen[fl.Pointer()] = true
+			defer delete(seen, fl.Pointer())
+		}
+
+		return validateNested(fl.Elem(), path, errs, seen)
+
+	case reflect.Struct:
+		if fl.Type().Implements(textMarshalerType) || reflect.PtrTo(fl.Type()).Implements(textMarshalerType) {
+			return nil
+		}
+
+		return validateStruct(fl, path, errs, seen)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fl.Len(); i++ {
+			if err := validateNested(fl.Index(i), fmt.Sprintf("%s[%d]", path, i), errs, seen); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		for _, key := range fl.MapKeys() {
+			if err := validateNested(fl.MapIndex(key), joinPath(path, fmt.Sprintf("%v", key.Interface())), errs, seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonName returns the name of the field in its json tag or the field name.
+func jsonName(sf reflect.StructField) string {
+	name, _ := parseTag(sf.Tag.Get("json"))
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+
+	return name
+}
+
+// isZeroValue returns true/false if the value is empty for the required rule.
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+//==============================================================================
+
+// size returns the size compared by the min and max rules.
+func size(value reflect.Value) (float64, string, error) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), "length", nil
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), "length", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "value", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "value", nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "value", nil
+	default:
+		return 0, "", fmt.Errorf("can not compare size of %s", value.Kind())
+	}
+}
+
+func validateMin(value reflect.Value, param string, _ reflect.Value) error {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid min %q", param)
+	}
+
+	val, kind, err := size(value)
+	if err != nil {
+		return err
+	}
+
+	if val < bound {
+		return fmt.Errorf("%s must be at least %s", kind, param)
+	}
+
+	return nil
+}
+
+func validateMax(value reflect.Value, param string, _ reflect.Value) error {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid max %q", param)
+	}
+
+	val, kind, err := size(value)
+	if err != nil {
+		return err
+	}
+
+	if val > bound {
+		return fmt.Errorf("%s must be at most %s", kind, param)
+	}
+
+	return nil
+}
+
+func validateEmail(value reflect.Value, _ string, _ reflect.Value) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("email rule requires a string, not %s", value.Kind())
+	}
+
+	addr, err := mail.ParseAddress(value.String())
+	if err != nil || addr.Address != value.String() {
+		return fmt.Errorf("must be a valid email address")
+	}
+
+	return nil
+}
+
+func validateOneOf(value reflect.Value, param string, _ reflect.Value) error {
+	if !value.IsValid() {
+		return fmt.Errorf("must be one of [%s]", param)
+	}
+
+	val := fmt.Sprintf("%v", value.Interface())
+
+	for _, item := range strings.Fields(param) {
+		if item == val {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of [%s]", param)
+}
+
+var regexes = struct {
+	rl    sync.Mutex
+	items map[string]*regexp.Regexp
+}{
+	items: make(map[string]*regexp.Regexp),
+}
+
+func validateRegex(value reflect.Value, param string, _ reflect.Value) error {
+	if value.Kind() != reflect.String {
+		return fmt.Errorf("regex rule requires a string, not %s", value.Kind())
+	}
+
+	regexes.rl.Lock()
+	rx, ok := regexes.items[param]
+	if !ok {
+		var err error
+		if rx, err = regexp.Compile(param); err != nil {
+			regexes.rl.Unlock()
+			return fmt.Errorf("invalid regex %q: %s", param, err)
+		}
+
+		regexes.items[param] = rx
+	}
+	regexes.rl.Unlock()
+
+	if !rx.MatchString(value.String()) {
+		return fmt.Errorf("must match %s", param)
+	}
+
+	return nil
+}
+
+func validateEqField(value reflect.Value, param string, parent reflect.Value) error {
+	other := parent.FieldByName(param)
+	if !other.IsValid() {
+		return fmt.Errorf("unknown field %q", param)
+	}
+
+	for other.Kind() == reflect.Ptr && !other.IsNil() {
+		other = other.Elem()
+	}
+
+	if !value.IsValid() || !other.CanInterface() || !reflect.DeepEqual(value.Interface(), other.Interface()) {
+		return fmt.Errorf("must be equal to %s", param)
+	}
+
+	return nil
+}
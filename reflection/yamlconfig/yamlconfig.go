@@ -0,0 +1,74 @@
+// Package yamlconfig adds YAML support to reflection.DecodeRaw and
+// reflection.DecodeConfig, which only decode JSON by themselves. Import it for
+// its side effect where YAML configs are needed:
+//
+//	import _ "github.com/influx6/faux/reflection/yamlconfig"
+package yamlconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/influx6/faux/reflection"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	reflection.RegisterRawDecoder("yaml", Decode)
+}
+
+// Decode decodes the raw YAML into the types returned by reflection.DecodeRaw,
+// keeping integers exact as json.Number.
+func Decode(raw []byte) (interface{}, error) {
+	var value interface{}
+
+	if err := yaml.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	return fromYAML(value)
+}
+
+// fromYAML converts the decoded YAML value into the types used by
+// encoding/json.
+func fromYAML(value interface{}) (interface{}, error) {
+	switch item := value.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(item))
+		for key, elem := range item {
+			conv, err := fromYAML(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			res[fmt.Sprintf("%v", key)] = conv
+		}
+
+		return res, nil
+
+	case []interface{}:
+		res := make([]interface{}, len(item))
+		for index, elem := range item {
+			conv, err := fromYAML(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			res[index] = conv
+		}
+
+		return res, nil
+
+	case int:
+		return json.Number(strconv.Itoa(item)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(item, 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(item, 10)), nil
+	case float64, string, bool, nil:
+		return item, nil
+	default:
+		return nil, fmt.Errorf("yamlconfig: unsupported YAML value %T", value)
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/influx6/faux/reflection"
 )
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 // Do provides a instruction struct for building a publisher from pub.
 type Do struct {
 	Tag  string      // a custom tag to use for result.
@@ -60,8 +63,26 @@ type Meta struct {
 	injectVal reflect.Value
 }
 
-// Build creates a new Publisher using the received config value.
+// Build creates a new Publisher using the received config value, it panics if
+// the build fails, see BuildE.
 func (p Meta) Build(config interface{}) interface{} {
+	pub, err := p.BuildE(config)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return pub
+}
+
+// BuildE creates a new Publisher using the received config value, returning
+// an error if the config can not be used for the Inject function, if the
+// function does not return a value, if it returns a non-nil error as its
+// second value or if it panics.
+func (p Meta) BuildE(config interface{}) (interface{}, error) {
+	if !reflection.IsFuncType(p.Inject) {
+		return nil, fmt.Errorf("Meta[%s] in Pkg[%s] has no Inject function", p.Name, p.Package)
+	}
+
 	if p.injectArg == nil {
 		args, _ := reflection.GetFuncArgumentsType(p.Inject)
 		p.injectArg = args
@@ -74,7 +95,16 @@ func (p Meta) Build(config interface{}) interface{} {
 	var configVal reflect.Value
 
 	if config == nil || len(p.injectArg) == 0 {
-		vak = p.injectVal.Call(nil)
+		if len(p.injectArg) != 0 {
+			return nil, fmt.Errorf("Meta[%s] in Pkg[%s] requires a %s config", p.Name, p.Package, p.injectArg[0])
+		}
+
+		res, err := p.call(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		vak = res
 	} else {
 
 		wanted := p.injectArg[0]
@@ -82,7 +112,7 @@ func (p Meta) Build(config interface{}) interface{} {
 
 		if !ctype.AssignableTo(wanted) {
 			if !ctype.ConvertibleTo(wanted) {
-				panic(fmt.Sprintf("Unassignable value for Inject: %s -> %+s", query(config), wanted))
+				return nil, fmt.Errorf("Unassignable value for Inject: %s -> %+s", query(config), wanted)
 			}
 
 			vum := reflect.ValueOf(config)
@@ -91,20 +121,92 @@ func (p Meta) Build(config interface{}) interface{} {
 			configVal = reflect.ValueOf(config)
 		}
 
-		vak = p.injectVal.Call([]reflect.Value{configVal})
+		res, err := p.call([]reflect.Value{configVal})
+		if err != nil {
+			return nil, err
+		}
+
+		vak = res
 	}
 
 	if len(vak) == 0 {
-		panic(fmt.Sprintf("Meta[%s] in Pkg[%s] returns no value", p.Name, p.Package))
+		return nil, fmt.Errorf("Meta[%s] in Pkg[%s] returns no value", p.Name, p.Package)
 	}
 
-	if len(vak) > 1 {
-		panic(fmt.Sprintf("Meta[%s] in Pkg[%s] returns values greater than 1", p.Name, p.Package))
+	if len(vak) > 2 || (len(vak) == 2 && vak[1].Type() != errorType) {
+		return nil, fmt.Errorf("Meta[%s] in Pkg[%s] returns values greater than 1", p.Name, p.Package)
+	}
+
+	if len(vak) == 2 && !vak[1].IsNil() {
+		return nil, vak[1].Interface().(error)
 	}
 
 	pubMade := vak[0]
 
-	return (pubMade.Interface())
+	return (pubMade.Interface()), nil
+}
+
+// call calls the Inject function with the giving arguments, returning a panic
+// of the function as an error.
+func (p Meta) call(args []reflect.Value) (res []reflect.Value, err error) {
+	defer func() {
+		if ex := recover(); ex != nil {
+			err = fmt.Errorf("Meta[%s] in Pkg[%s] panicked: %v", p.Name, p.Package, ex)
+		}
+	}()
+
+	return p.injectVal.Call(args), nil
+}
+
+// ConfigType returns the type of the config expected by the Inject function
+// or nil if it takes none.
+func (p Meta) ConfigType() reflect.Type {
+	args, err := reflection.GetFuncArgumentsType(p.Inject)
+	if err != nil || len(args) == 0 {
+		return nil
+	}
+
+	return args[0]
+}
+
+// Schema returns the JSON Schema of the config expected by the Inject function,
+// see reflection.SchemaOf. It returns an error if it takes no config.
+func (p Meta) Schema() (*reflection.Schema, error) {
+	config := p.ConfigType()
+	if config == nil {
+		return nil, fmt.Errorf("Meta[%s] in Pkg[%s] takes no config", p.Name, p.Package)
+	}
+
+	return reflection.SchemaOf(config)
+}
+
+// Decode returns the config expected by the Inject function decoded from the
+// raw JSON or YAML, after validating it against the Schema of the config, see
+// reflection.DecodeConfig. YAML requires importing reflection/yamlconfig. It
+// returns nil if the Inject function takes no config.
+func (p Meta) Decode(raw []byte) (interface{}, error) {
+	config := p.ConfigType()
+	if config == nil {
+		return nil, nil
+	}
+
+	target := reflect.New(config)
+	if err := reflection.DecodeConfig(raw, target.Interface()); err != nil {
+		return nil, fmt.Errorf("Meta[%s] in Pkg[%s] has invalid config: %s", p.Name, p.Package, err)
+	}
+
+	return target.Elem().Interface(), nil
+}
+
+// BuildRaw creates a new Publisher using the config decoded from the raw JSON
+// or YAML, see Decode.
+func (p Meta) BuildRaw(raw []byte) (interface{}, error) {
+	config, err := p.Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.BuildE(config)
 }
 
 // Validate ensures the Meta provides the necessary information needed
@@ -135,6 +237,15 @@ func (p Meta) Validate() error {
 		return errors.New("Argument Size Greater Than 1")
 	}
 
+	// Must return a value or a value and an error.
+	ft, _ := reflection.FuncType(p.Inject)
+	switch {
+	case ft.NumOut() == 1:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		return errors.New("Inject must return a value or a value and an error")
+	}
+
 	return nil
 }
 
@@ -155,17 +266,26 @@ func New() *Regus {
 // panic if there exists a similar registered buildable structures with
 // the provided Meta.Name.
 func (regus *Regus) Register(meta Meta) {
+	if err := regus.register(meta); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryRegister adds a new Publisher constructor into the registery like
+// Register, returning an error instead of panicking.
+func (regus *Regus) TryRegister(meta Meta) error {
+	return regus.register(meta)
+}
+
+// register adds the meta into the registery, it must be called directly by
+// Register or TryRegister to find the package of their caller.
+func (regus *Regus) register(meta Meta) error {
 	if regus.Has(meta.Name) {
-		panic(fmt.Sprintf("Name[%s] already assigned", meta.Name))
+		return fmt.Errorf("Name[%s] already assigned", meta.Name)
 	}
 
 	if meta.Package == "" {
-		tm := reflect.TypeOf(meta.Inject)
-		if tm.Kind() == reflect.Ptr {
-			tm = tm.Elem()
-		}
-
-		pc, _, _, _ := runtime.Caller(2)
+		pc, _, _, _ := runtime.Caller(3)
 		pkg, pkgName := splitPath(runtime.FuncForPC(pc).Name())
 		parts := strings.Split(pkgName, ".")
 		plen := len(parts)
@@ -182,12 +302,18 @@ func (regus *Regus) Register(meta Meta) {
 	}
 
 	if err := meta.Validate(); err != nil {
-		panic(fmt.Sprintf("Meta[%s] is Invalid: %s", meta.Name, err))
+		return fmt.Errorf("Meta[%s] is Invalid: %s", meta.Name, err)
 	}
 
 	regus.Lock()
+	defer regus.Unlock()
+
+	if _, ok := regus.pubs[meta.Name]; ok {
+		return fmt.Errorf("Name[%s] already assigned", meta.Name)
+	}
+
 	regus.pubs[meta.Name] = meta
-	regus.Unlock()
+	return nil
 }
 
 // Get returns the Meta associated with a name if it exists.
@@ -228,6 +354,92 @@ func (regus *Regus) NewBuild(name string, config interface{}) interface{} {
 	return meta.Build(config)
 }
 
+// NewBuildE returns a new Publisher like NewBuild, returning an error instead
+// of panicking if the injector is not found or fails to build.
+func (regus *Regus) NewBuildE(name string, config interface{}) (interface{}, error) {
+	meta, err := regus.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("Pub.Meta for Publisher[%s] does not exists", name)
+	}
+
+	return meta.BuildE(config)
+}
+
+// NewBuildRaw returns a new Publisher using the config decoded from the raw
+// JSON or YAML for the injector with the giving name, see Meta.BuildRaw.
+func (regus *Regus) NewBuildRaw(name string, raw []byte) (interface{}, error) {
+	meta, err := regus.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("Pub.Meta for Publisher[%s] does not exists", name)
+	}
+
+	return meta.BuildRaw(raw)
+}
+
+// Decode returns the config decoded from the raw JSON or YAML for the injector
+// with the giving name without building it, which allows checking configs
+// ahead of use, see Meta.Decode.
+func (regus *Regus) Decode(name string, raw []byte) (interface{}, error) {
+	meta, err := regus.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("Pub.Meta for Publisher[%s] does not exists", name)
+	}
+
+	return meta.Decode(raw)
+}
+
+// Description defines the details of a registered Meta.
+type Description struct {
+	Name    string             `json:"name"`
+	Desc    string             `json:"desc"`
+	Package string             `json:"package"`
+	Config  string             `json:"config,omitempty"`
+	Schema  *reflection.Schema `json:"schema,omitempty"`
+}
+
+// Describe returns the Description of the Meta registered with the name.
+func (regus *Regus) Describe(name string) (Description, error) {
+	meta, err := regus.Get(name)
+	if err != nil {
+		return Description{}, err
+	}
+
+	return describe(meta), nil
+}
+
+// List returns the Description of all registered Meta sorted by name.
+func (regus *Regus) List() []Description {
+	regus.RLock()
+	defer regus.RUnlock()
+
+	list := make([]Description, 0, len(regus.pubs))
+	for _, meta := range regus.pubs {
+		list = append(list, describe(meta))
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// describe returns the Description of the giving Meta.
+func describe(meta Meta) Description {
+	desc := Description{
+		Name:    meta.Name,
+		Desc:    meta.Desc,
+		Package: meta.Package,
+	}
+
+	if config := meta.ConfigType(); config != nil {
+		desc.Config = config.String()
+		desc.Schema, _ = reflection.SchemaOf(config)
+	}
+
+	return desc
+}
+
 // splitAndLastSlash splits a string by a formward slash and returns the left
 // and right parts of it.
 func splitPath(line string) (string, string) {
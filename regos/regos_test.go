@@ -0,0 +1,175 @@
+package regos_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/influx6/faux/reflection/yamlconfig"
+	"github.com/influx6/faux/regos"
+)
+
+type config struct {
+	Addr string `json:"addr" validate:"required"`
+	Port int    `json:"port" default:"80"`
+}
+
+func TestTryRegister(t *testing.T) {
+	reg := regos.New()
+
+	if err := reg.TryRegister(regos.Meta{Name: "bad", Desc: "bad builder"}); err == nil {
+		t.Fatalf("Should have failed to register Meta without Inject")
+	}
+	t.Logf("Should have failed to register Meta without Inject")
+
+	meta := regos.Meta{
+		Name: "server",
+		Desc: "builds a server address",
+		Inject: func(c config) string {
+			return c.Addr
+		},
+	}
+
+	if err := reg.TryRegister(meta); err != nil {
+		t.Fatalf("Should have registered Meta: %s", err)
+	}
+	t.Logf("Should have registered Meta")
+
+	if err := reg.TryRegister(meta); err == nil {
+		t.Fatalf("Should have failed to register duplicate Meta")
+	}
+	t.Logf("Should have failed to register duplicate Meta")
+
+	desc, err := reg.Describe("server")
+	if err != nil {
+		t.Fatalf("Should have described Meta: %s", err)
+	}
+
+	if desc.Package == "" {
+		t.Fatalf("Should have set package of Meta")
+	}
+	t.Logf("Should have set package of Meta")
+
+	if desc.Config != "regos_test.config" {
+		t.Fatalf("Should have described config type: %q", desc.Config)
+	}
+	t.Logf("Should have described config type")
+}
+
+func TestBuildE(t *testing.T) {
+	reg := regos.New()
+
+	failed := errors.New("bad address")
+
+	reg.Register(regos.Meta{
+		Name: "server",
+		Desc: "builds a server address",
+		Inject: func(c config) (string, error) {
+			if c.Addr == "" {
+				return "", failed
+			}
+
+			return c.Addr, nil
+		},
+	})
+
+	reg.Register(regos.Meta{
+		Name: "client",
+		Desc: "builds a client",
+		Inject: func() int {
+			return 1
+		},
+	})
+
+	addr, err := reg.NewBuildE("server", config{Addr: ":4050"})
+	if err != nil {
+		t.Fatalf("Should have built server: %s", err)
+	}
+
+	if addr != ":4050" {
+		t.Fatalf("Should have built server address: %v", addr)
+	}
+	t.Logf("Should have built server address")
+
+	if _, err := reg.NewBuildE("server", config{}); err != failed {
+		t.Fatalf("Should have returned the factory error: %v", err)
+	}
+	t.Logf("Should have returned the factory error")
+
+	if _, err := reg.NewBuildE("server", 20); err == nil {
+		t.Fatalf("Should have failed to build with invalid config")
+	}
+	t.Logf("Should have failed to build with invalid config")
+
+	if _, err := reg.NewBuildE("unknown", nil); err == nil {
+		t.Fatalf("Should have failed to build unknown Meta")
+	}
+	t.Logf("Should have failed to build unknown Meta")
+
+	list := reg.List()
+	if len(list) != 2 || list[0].Name != "client" || list[1].Name != "server" {
+		t.Fatalf("Should have listed Meta sorted by name: %+v", list)
+	}
+	t.Logf("Should have listed Meta sorted by name")
+}
+
+func TestBuildEPanic(t *testing.T) {
+	reg := regos.New()
+
+	reg.Register(regos.Meta{
+		Name: "broken",
+		Desc: "panics while building",
+		Inject: func(c config) string {
+			panic("boom")
+		},
+	})
+
+	_, err := reg.NewBuildE("broken", config{Addr: ":4050"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("Should have returned factory panic as error: %v", err)
+	}
+	t.Logf("Should have returned factory panic as error")
+
+	if _, err := reg.NewBuildRaw("broken", []byte(`{"addr": ":4050"}`)); err == nil {
+		t.Fatalf("Should have returned factory panic as error from raw config")
+	}
+	t.Logf("Should have returned factory panic as error from raw config")
+}
+
+func TestBuildRaw(t *testing.T) {
+	reg := regos.New()
+
+	reg.Register(regos.Meta{
+		Name: "server",
+		Desc: "builds a server address",
+		Inject: func(c config) string {
+			return fmt.Sprintf("%s:%d", c.Addr, c.Port)
+		},
+	})
+
+	addr, err := reg.NewBuildRaw("server", []byte("addr: localhost\n"))
+	if err != nil {
+		t.Fatalf("Should have built server from YAML: %s", err)
+	}
+
+	if addr != "localhost:80" {
+		t.Fatalf("Should have built server with default port: %v", addr)
+	}
+	t.Logf("Should have built server with default port")
+
+	if _, err := reg.NewBuildRaw("server", []byte(`{"port": 90}`)); err == nil {
+		t.Fatalf("Should have failed to build server with missing addr")
+	}
+	t.Logf("Should have failed to build server with missing addr")
+
+	desc, err := reg.Describe("server")
+	if err != nil {
+		t.Fatalf("Should have described Meta: %s", err)
+	}
+
+	if desc.Schema == nil || desc.Schema.Properties["port"].Default != float64(80) {
+		t.Fatalf("Should have described config schema: %s", desc.Schema)
+	}
+	t.Logf("Should have described config schema")
+}
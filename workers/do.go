@@ -1,6 +1,7 @@
 package workers
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/influx6/faux/regos"
@@ -28,37 +29,77 @@ func (r Works) Get(tag string) Worker {
 // Work defines a list of regos.DO actions.
 type Work []regos.Do
 
-// Make builds the Do instruction using the Make builder.
+// Check validates the Do instructions without building them, ensuring each
+// uses a registered worker and a unique tag, and that raw configs decode into
+// the config of their worker, see Make.
+func (d Work) Check() error {
+	tags := make(map[string]bool)
+
+	for _, do := range d {
+		if tags[do.Tag] {
+			return fmt.Errorf("Build Instruction for %s using reserved tag %s", do.Name, do.Tag)
+		}
+
+		tags[do.Tag] = true
+
+		if !Workers.Has(do.Name) {
+			return fmt.Errorf("Sumex has no Stream[%s] for Tag[%s]", do.Name, do.Tag)
+		}
+
+		if raw, ok := rawConfig(do.Use); ok {
+			if _, err := Workers.Decode(do.Name, raw); err != nil {
+				return fmt.Errorf("Sumex failed to check Stream[%s] with Tag[%s]: [%s]", do.Name, do.Tag, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Make builds the Do instruction using the Make builder. A Do.Use of
+// json.RawMessage or []byte is decoded as JSON or YAML into the config of
+// the worker, see regos.Meta.BuildRaw. A worker which fails or panics while
+// being built is returned as an error.
 func (d Work) Make() (Works, error) {
 	res := make(Works)
 
-	var err error
-
 	for _, do := range d {
 		if res.Has(do.Tag) {
-			err = fmt.Errorf("Build Instruction for %s using reserved tag %s", do.Name, do.Tag)
-			break
+			return nil, fmt.Errorf("Build Instruction for %s using reserved tag %s", do.Name, do.Tag)
 		}
 
-		if err != nil {
-			break
+		var pb interface{}
+		var err error
+
+		if raw, ok := rawConfig(do.Use); ok {
+			pb, err = Workers.NewBuildRaw(do.Name, raw)
+		} else {
+			pb, err = Workers.NewBuildE(do.Name, do.Use)
 		}
 
-		func() {
-			defer func() {
-				if ex := recover(); ex != nil {
-					err = fmt.Errorf("Sumex failed to build Stream[%s] with Tag[%s]: [%s]", do.Name, do.Tag, ex)
-				}
-			}()
+		if err != nil {
+			return nil, fmt.Errorf("Sumex failed to build Stream[%s] with Tag[%s]: [%s]", do.Name, do.Tag, err)
+		}
 
-			pb := Workers.NewBuild(do.Name, do.Use).(Worker)
-			res[do.Tag] = pb
-		}()
-	}
+		worker, ok := pb.(Worker)
+		if !ok {
+			return nil, fmt.Errorf("Sumex failed to build Stream[%s] with Tag[%s]: [%T is not a Worker]", do.Name, do.Tag, pb)
+		}
 
-	if err != nil {
-		return nil, err
+		res[do.Tag] = worker
 	}
 
 	return res, nil
 }
+
+// rawConfig returns the raw config held by the giving value if any.
+func rawConfig(use interface{}) ([]byte, bool) {
+	switch raw := use.(type) {
+	case json.RawMessage:
+		return raw, true
+	case []byte:
+		return raw, true
+	default:
+		return nil, false
+	}
+}